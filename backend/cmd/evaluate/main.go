@@ -0,0 +1,318 @@
+// Command evaluate replays saved personality quizzes through one or more
+// scoring strategies and measures how well each one predicts the perfumes the
+// quiz author went on to rate highly in reviews.
+//
+//	go run ./cmd/evaluate -k 6 -strategies current,no_diversity,popularity,random
+//	go run ./cmd/evaluate -strategies current,scent_profile
+//	go run ./cmd/evaluate -strategy-file strategies.json -json report.json
+//
+// A strategy file maps strategy names to recommendation settings, decoded on
+// top of the saved ones, e.g. {"notes_heavy": {"weights": {"notes": 0.4}}}.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"perfume-website/internal/config"
+	"perfume-website/internal/db"
+	"perfume-website/internal/models"
+	"perfume-website/internal/repositories"
+	"perfume-website/internal/services"
+)
+
+// strategy returns the ranked recommendations for one replayed quiz
+type strategy func(c models.EvaluationCase) ([]models.Perfume, error)
+
+func main() {
+	dbPath := flag.String("db", "", "database path (defaults to DATABASE_URL)")
+	k := flag.Int("k", 6, "number of recommendations to evaluate per quiz")
+	minRating := flag.Int("min-rating", 4, "lowest overall rating that counts as a hit")
+	names := flag.String("strategies", "current,no_diversity,popularity,random", "comma-separated strategies to compare")
+	strategyFile := flag.String("strategy-file", "", "JSON file of named settings overrides, added to the strategies")
+	jsonPath := flag.String("json", "", "also write the report as JSON to this file ('-' for stdout only)")
+	seed := flag.Int64("seed", 1, "seed for the random baseline")
+	flag.Parse()
+
+	if *k <= 0 {
+		log.Fatal("-k must be positive")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if *dbPath != "" {
+		cfg.DatabaseURL = *dbPath
+	}
+	database, err := db.NewDatabase(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.Close()
+
+	quizRepo := repositories.NewQuizRepository(database.GetDB())
+	perfumeRepo := repositories.NewPerfumeRepository(database.GetDB())
+	aromaRepo := repositories.NewAromaRepository(database.GetDB())
+	settingsRepo := repositories.NewRecommendationSettingsRepository(database.GetDB())
+	ratingRepo := repositories.NewReviewRatingRepository(database.GetDB())
+
+	quizzes, err := quizRepo.GetAllQuizzes()
+	if err != nil {
+		log.Fatalf("Failed to load quizzes: %v", err)
+	}
+	ratings, err := ratingRepo.GetAllRatings()
+	if err != nil {
+		log.Fatalf("Failed to load review ratings: %v", err)
+	}
+	catalog, err := perfumeRepo.GetAllWithRelations()
+	if err != nil {
+		log.Fatalf("Failed to load perfumes: %v", err)
+	}
+	// Every strategy scores against the same catalog, loaded once
+	catalogService := services.NewCatalogService(perfumeRepo, nil)
+	cases := services.BuildEvaluationCases(quizzes, ratings, *minRating)
+
+	current := services.NewRecommendationSettingsService(settingsRepo).Current()
+	overrides, order, err := loadStrategyFile(*strategyFile)
+	if err != nil {
+		log.Fatalf("Failed to load strategy file: %v", err)
+	}
+
+	newQuizStrategy := func(settings models.RecommendationSettings, algorithm string) (strategy, error) {
+		if err := services.ValidateRecommendationSettings(settings); err != nil {
+			return nil, err
+		}
+		payload, err := json.Marshal(settings)
+		if err != nil {
+			return nil, err
+		}
+		quizService := services.NewQuizService(*quizRepo, catalogService, aromaRepo,
+			services.NewRecommendationSettingsService(staticSettingsRepository{payload: string(payload)}), nil, nil)
+		return func(c models.EvaluationCase) ([]models.Perfume, error) {
+			req := quizRequest(c.Quiz, *k)
+			req.Algorithm = algorithm
+			response, err := quizService.GetAdvancedRecommendations(req)
+			if err != nil {
+				return nil, err
+			}
+			perfumes := make([]models.Perfume, len(response.Results))
+			for i, result := range response.Results {
+				perfumes[i] = result.Perfume
+			}
+			return perfumes, nil
+		}, nil
+	}
+
+	builtins := map[string]func() (strategy, error){
+		"current":       func() (strategy, error) { return newQuizStrategy(current, services.AlgorithmMultiFactor) },
+		"scent_profile": func() (strategy, error) { return newQuizStrategy(current, services.AlgorithmScentProfile) },
+		"no_diversity": func() (strategy, error) {
+			settings := current
+			settings.Diversity.Default = 0
+			return newQuizStrategy(settings, services.AlgorithmMultiFactor)
+		},
+		"popularity": func() (strategy, error) { return popularityStrategy(catalog, ratings, *minRating, *k), nil },
+		"random":     func() (strategy, error) { return randomStrategy(catalog, *seed, *k), nil },
+	}
+
+	report := models.EvaluationReport{
+		GeneratedAt: time.Now(),
+		K:           *k,
+		MinRating:   *minRating,
+		Quizzes:     len(quizzes),
+		Cases:       len(cases),
+		CatalogSize: len(catalog),
+	}
+	for _, name := range append(splitNames(*names), order...) {
+		var run strategy
+		if payload, ok := overrides[name]; ok {
+			settings, err := applyOverride(current, payload)
+			if err == nil {
+				run, err = newQuizStrategy(settings, services.AlgorithmMultiFactor)
+			}
+			if err != nil {
+				log.Fatalf("Invalid strategy %s: %v", name, err)
+			}
+		} else if build, ok := builtins[name]; ok {
+			if run, err = build(); err != nil {
+				log.Fatalf("Invalid strategy %s: %v", name, err)
+			}
+		} else {
+			log.Fatalf("Unknown strategy %q", name)
+		}
+
+		rankings := make([][]models.Perfume, len(cases))
+		rejected := 0
+		for i, c := range cases {
+			ranking, err := run(c)
+			if err != nil {
+				if !errors.Is(err, services.ErrInvalidQuizRequest) {
+					log.Fatalf("Strategy %s failed on quiz %d: %v", name, c.Quiz.ID, err)
+				}
+				rejected++
+			}
+			rankings[i] = ranking
+		}
+		metrics := services.EvaluateRankings(name, cases, rankings, *k, len(catalog), current.Diversity)
+		metrics.Rejected = rejected
+		report.Strategies = append(report.Strategies, metrics)
+	}
+
+	if *jsonPath == "-" {
+		if err := writeJSON(os.Stdout, report); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+		return
+	}
+	writeTable(os.Stdout, report)
+	if *jsonPath != "" {
+		file, err := os.Create(*jsonPath)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *jsonPath, err)
+		}
+		defer file.Close()
+		if err := writeJSON(file, report); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	}
+}
+
+// quizRequest turns a saved quiz into the request it would have made, dated
+// when it was taken so the detected season matches
+func quizRequest(quiz models.PersonalityQuiz, k int) models.AdvancedRecommendationRequest {
+	req := models.AdvancedRecommendationRequest{
+		QuizPreferences: quiz.Preferences,
+		MaxResults:      k,
+	}
+	if !quiz.CreatedAt.IsZero() {
+		req.Date = quiz.CreatedAt.Format("2006-01-02")
+	}
+	return req
+}
+
+// popularityStrategy recommends the perfumes most often rated highly by other
+// reviewers, leaving out the quiz author's own ratings
+func popularityStrategy(catalog []models.Perfume, ratings []models.ItemRating, minRating, k int) strategy {
+	loved := make(map[uint]int)
+	lovedBy := make(map[string]map[uint]bool)
+	for _, rating := range ratings {
+		if rating.Rating < minRating {
+			continue
+		}
+		loved[rating.PerfumeID]++
+		if lovedBy[rating.Reviewer] == nil {
+			lovedBy[rating.Reviewer] = make(map[uint]bool)
+		}
+		lovedBy[rating.Reviewer][rating.PerfumeID] = true
+	}
+
+	return func(c models.EvaluationCase) ([]models.Perfume, error) {
+		count := func(p models.Perfume) int {
+			if lovedBy[c.Reviewer][p.ID] {
+				return loved[p.ID] - 1
+			}
+			return loved[p.ID]
+		}
+		ranked := append([]models.Perfume(nil), catalog...)
+		sort.SliceStable(ranked, func(i, j int) bool {
+			if a, b := count(ranked[i]), count(ranked[j]); a != b {
+				return a > b
+			}
+			return ranked[i].ID < ranked[j].ID
+		})
+		return ranked[:min(k, len(ranked))], nil
+	}
+}
+
+// randomStrategy recommends k perfumes at random, as a floor for the other strategies
+func randomStrategy(catalog []models.Perfume, seed int64, k int) strategy {
+	rng := rand.New(rand.NewSource(seed))
+	return func(models.EvaluationCase) ([]models.Perfume, error) {
+		picked := make([]models.Perfume, 0, k)
+		for _, i := range rng.Perm(len(catalog))[:min(k, len(catalog))] {
+			picked = append(picked, catalog[i])
+		}
+		return picked, nil
+	}
+}
+
+// loadStrategyFile reads named settings overrides, keeping the order they appear in
+func loadStrategyFile(path string) (map[string]json.RawMessage, []string, error) {
+	if path == "" {
+		return nil, nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var overrides map[string]json.RawMessage
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, nil, err
+	}
+	order := make([]string, 0, len(overrides))
+	for name := range overrides {
+		order = append(order, name)
+	}
+	sort.Strings(order)
+	return overrides, order, nil
+}
+
+// applyOverride decodes a partial settings payload on top of base
+func applyOverride(base models.RecommendationSettings, payload json.RawMessage) (models.RecommendationSettings, error) {
+	settings := base
+	err := json.Unmarshal(payload, &settings)
+	return settings, err
+}
+
+func splitNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func writeTable(w io.Writer, report models.EvaluationReport) {
+	fmt.Fprintf(w, "%d of %d quizzes have ground truth (rating >= %d), catalog of %d perfumes\n\n",
+		report.Cases, report.Quizzes, report.MinRating, report.CatalogSize)
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(table, "strategy\tP@%d\tR@%d\tNDCG@%d\tcoverage\tdiversity\trejected\t\n", report.K, report.K, report.K)
+	for _, m := range report.Strategies {
+		fmt.Fprintf(table, "%s\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t%d\t\n",
+			m.Strategy, m.Precision, m.Recall, m.NDCG, m.Coverage, m.Diversity, m.Rejected)
+	}
+	table.Flush()
+}
+
+func writeJSON(w io.Writer, report models.EvaluationReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// staticSettingsRepository serves one fixed settings payload so each strategy
+// runs through the same code path as the live recommender
+type staticSettingsRepository struct {
+	payload string
+}
+
+func (r staticSettingsRepository) GetLatest() (*models.RecommendationSettingsRecord, error) {
+	return &models.RecommendationSettingsRecord{Payload: r.payload, UpdatedBy: "evaluate"}, nil
+}
+
+func (r staticSettingsRepository) Create(*models.RecommendationSettingsRecord) error {
+	return errors.New("evaluation settings are read-only")
+}
@@ -28,14 +28,23 @@ func main() {
 	}
 	defer database.Close()
 
-	// Database is already populated with CSV import data
+	// Database is already populated with CSV import data; apply additive migrations
+	if err := database.Migrate(); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
 
 	// Initialize repositories
 	adminRepo := repositories.NewAdminRepository(database.GetDB())
 	perfumeRepo := repositories.NewPerfumeRepository(database.GetDB())
 	aromaRepo := repositories.NewAromaRepository(database.GetDB())
 	quizRepo := repositories.NewQuizRepository(database.GetDB())
+	translationRepo := repositories.NewTranslationRepository(database.GetDB())
+	settingsRepo := repositories.NewRecommendationSettingsRepository(database.GetDB())
+	reviewRatingRepo := repositories.NewReviewRatingRepository(database.GetDB())
 	enhancedReviewRepo := models.NewEnhancedReviewRepositoryGORM(database.GetDB())
+	experimentRepo := repositories.NewExperimentRepository(database.GetDB())
+	historyRepo := repositories.NewRecommendationHistoryRepository(database.GetDB())
+	quizSessionRepo := repositories.NewQuizSessionRepository(database.GetDB())
 
 	// Run auto migration for enhanced reviews
 	if err := enhancedReviewRepo.AutoMigrate(); err != nil {
@@ -43,18 +52,55 @@ func main() {
 	}
 
 	// Initialize services
+	catalogNotifier := services.NewCatalogNotifier()
+	catalogService := services.NewCatalogService(perfumeRepo, catalogNotifier)
 	authService := services.NewAuthService(adminRepo, cfg.JWTSecret)
-	perfumeService := services.NewPerfumeService(perfumeRepo, aromaRepo)
-	aromaService := services.NewAromaService(aromaRepo)
-	quizService := services.NewQuizService(*quizRepo, perfumeRepo, aromaRepo)
-	enhancedReviewService := services.NewEnhancedReviewService(enhancedReviewRepo)
+	perfumeService := services.NewPerfumeService(perfumeRepo, aromaRepo, catalogNotifier, catalogService)
+	aromaService := services.NewAromaService(aromaRepo, perfumeRepo, catalogNotifier)
+	settingsService := services.NewRecommendationSettingsService(settingsRepo)
+	similarityService := services.NewItemSimilarityService(reviewRatingRepo, catalogService)
+	quizService := services.NewQuizService(*quizRepo, catalogService, aromaRepo, settingsService, similarityService, services.NewStaticWeatherProvider())
+	enhancedReviewService := services.NewEnhancedReviewService(enhancedReviewRepo, similarityService)
+	translationService := services.NewTranslationService(translationRepo, cfg.DefaultLocale, cfg.SupportedLocales)
+	experimentService := services.NewExperimentService(experimentRepo, settingsService, perfumeService, quizService)
+	historyService := services.NewRecommendationHistoryService(historyRepo, settingsService)
+	layeringService := services.NewLayeringService(catalogService)
+	wardrobeService := services.NewWardrobeService(catalogService, settingsService)
+	adaptiveQuizService := services.NewAdaptiveQuizService(quizService, quizSessionRepo)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
-	perfumeHandler := handlers.NewPerfumeHandler(perfumeService)
-	aromaHandler := handlers.NewAromaHandler(aromaService)
-	quizHandler := handlers.NewQuizHandler(quizService)
-	enhancedReviewHandler := handlers.NewEnhancedReviewHandler(enhancedReviewService)
+	perfumeHandler := handlers.NewPerfumeHandler(perfumeService, translationService, experimentService, historyService)
+	aromaHandler := handlers.NewAromaHandler(aromaService, translationService)
+	quizHandler := handlers.NewQuizHandler(quizService, translationService, experimentService, historyService)
+	enhancedReviewHandler := handlers.NewEnhancedReviewHandler(enhancedReviewService, experimentService)
+	translationHandler := handlers.NewTranslationHandler(translationService)
+	settingsHandler := handlers.NewRecommendationSettingsHandler(settingsService)
+	similarityHandler := handlers.NewSimilarityHandler(similarityService, translationService)
+	experimentHandler := handlers.NewExperimentHandler(experimentService)
+	historyHandler := handlers.NewRecommendationHistoryHandler(historyService, experimentService)
+	layeringHandler := handlers.NewLayeringHandler(layeringService, translationService)
+	wardrobeHandler := handlers.NewWardrobeHandler(wardrobeService, translationService)
+	catalogHandler := handlers.NewCatalogHandler(catalogService)
+	adaptiveQuizHandler := handlers.NewAdaptiveQuizHandler(adaptiveQuizService, translationService)
+
+	// Load the catalog the recommenders read from, then reload it periodically to pick up imports
+	if _, err := catalogService.Rebuild(); err != nil {
+		log.Printf("Failed to load catalog: %v", err)
+	}
+	stopCatalog := catalogService.Start(cfg.CatalogRefreshInterval)
+	defer stopCatalog()
+
+	// Build the review-based similarity model, then keep it fresh in the background
+	if err := similarityService.Rebuild(); err != nil {
+		log.Printf("Failed to build item similarity model: %v", err)
+	}
+	stopSimilarity := similarityService.Start(cfg.SimilarityRebuildInterval)
+	defer stopSimilarity()
+
+	// Pick up recommendation settings saved by other server instances
+	stopSettings := settingsService.Start(cfg.SettingsReloadInterval)
+	defer stopSettings()
 
 	// Set Gin mode
 	if cfg.Environment == "production" {
@@ -68,7 +114,8 @@ func main() {
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept-Language, X-Anonymous-ID, X-Session-ID")
+		c.Header("Access-Control-Expose-Headers", "X-Anonymous-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -85,6 +132,8 @@ func main() {
 
 	// Public routes
 	api := router.Group("/api")
+	api.Use(middleware.LocaleMiddleware(translationService))
+	api.Use(middleware.AnonymousIDMiddleware())
 	{
 		// Authentication
 		api.POST("/auth/login", authHandler.Login)
@@ -92,17 +141,25 @@ func main() {
 		// Public perfume endpoints
 		api.GET("/perfumes", perfumeHandler.GetAllPerfumes)
 		api.GET("/perfumes/:id", perfumeHandler.GetPerfume)
+		api.GET("/perfumes/:id/also-loved", similarityHandler.GetAlsoLoved)
+		api.GET("/perfumes/:id/alternatives", similarityHandler.GetAlternatives)
 		api.POST("/recommend", perfumeHandler.RecommendPerfumes)
+		api.POST("/recommend/layering", layeringHandler.RecommendLayering)
+		api.POST("/recommend/wardrobe-gaps", wardrobeHandler.GetWardrobeGaps)
 
 		// Public aroma endpoints
 		api.GET("/aromas", aromaHandler.GetAllAromas)
+		api.GET("/aromas/stats", aromaHandler.GetAromaStats)
 		api.GET("/aromas/:id", aromaHandler.GetAroma)
 
 		// Quiz endpoints
 		api.POST("/quiz/recommendations", quizHandler.GetAdvancedRecommendations)
+		api.POST("/quiz/recommendations/:id/feedback", historyHandler.RecordFeedback)
 		api.POST("/quiz/save", quizHandler.SaveQuizResponse)
 		api.GET("/quiz/stats", quizHandler.GetQuizStats)
 		api.GET("/quiz/personality-types", quizHandler.GetPersonalityTypes)
+		api.POST("/quiz/sessions", adaptiveQuizHandler.StartSession)
+		api.POST("/quiz/sessions/:id/answers", adaptiveQuizHandler.AnswerQuestion)
 
 		// Enhanced Review endpoints
 		api.POST("/enhanced-reviews", enhancedReviewHandler.CreateEnhancedReview)
@@ -111,6 +168,13 @@ func main() {
 		api.GET("/enhanced-reviews/:id", enhancedReviewHandler.GetReviewByID)
 		api.POST("/enhanced-reviews/:id/helpful", enhancedReviewHandler.MarkReviewHelpful)
 		api.POST("/enhanced-reviews/:id/report", enhancedReviewHandler.ReportReview)
+
+		// Experiment outcomes
+		api.POST("/experiments/events", experimentHandler.RecordEvent)
+
+		// Recommendation history, grouped by the X-Session-ID header
+		api.GET("/recommendations/history", historyHandler.GetHistory)
+		api.POST("/recommendations/:id/events", historyHandler.RecordEvent)
 	}
 
 	// Protected routes (admin only)
@@ -123,12 +187,31 @@ func main() {
 		// Admin perfume management
 		admin.POST("/perfumes", perfumeHandler.CreatePerfume)
 		admin.PUT("/perfumes/:id", perfumeHandler.UpdatePerfume)
+		admin.PUT("/perfumes/:id/accords", perfumeHandler.UpdatePerfumeAccords)
 		admin.DELETE("/perfumes/:id", perfumeHandler.DeletePerfume)
+		admin.POST("/catalog/rebuild", catalogHandler.RebuildCatalog)
 
 		// Admin aroma management
 		admin.POST("/aromas", aromaHandler.CreateAroma)
 		admin.PUT("/aromas/:id", aromaHandler.UpdateAroma)
 		admin.DELETE("/aromas/:id", aromaHandler.DeleteAroma)
+
+		// Admin translation management
+		admin.GET("/translations", translationHandler.GetTranslations)
+		admin.PUT("/translations", translationHandler.UpsertTranslation)
+		admin.DELETE("/translations/:id", translationHandler.DeleteTranslation)
+		admin.GET("/translations/missing", translationHandler.GetMissingTranslations)
+
+		// Admin recommendation tuning
+		admin.GET("/recommendation-settings", settingsHandler.GetSettings)
+		admin.PUT("/recommendation-settings", settingsHandler.UpdateSettings)
+
+		// Admin A/B experiments
+		admin.GET("/experiments", experimentHandler.ListExperiments)
+		admin.POST("/experiments", experimentHandler.CreateExperiment)
+		admin.PUT("/experiments/:id/status", experimentHandler.UpdateExperimentStatus)
+		admin.GET("/experiments/:id/report", experimentHandler.GetExperimentReport)
+		admin.GET("/recommendations/unclicked", historyHandler.GetUnclickedPerfumes)
 	}
 
 	// Start server
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,6 +16,15 @@ type Config struct {
 	Environment     string
 	UploadPath      string
 	DatabaseDriver  string
+	DefaultLocale   string
+	SupportedLocales []string
+
+	// SimilarityRebuildInterval is how often the review-based similarity model is rebuilt; 0 disables it
+	SimilarityRebuildInterval time.Duration
+	// CatalogRefreshInterval is how often the in-memory catalog is reloaded to pick up imports; 0 disables it
+	CatalogRefreshInterval time.Duration
+	// SettingsReloadInterval is how often recommendation settings saved by other server instances are picked up; 0 disables it
+	SettingsReloadInterval time.Duration
 }
 
 func LoadConfig() (*Config, error) {
@@ -30,7 +41,27 @@ func LoadConfig() (*Config, error) {
 		Environment:     getEnv("ENVIRONMENT", "development"),
 		UploadPath:      getEnv("UPLOAD_PATH", "./uploads"),
 		DatabaseDriver:  getEnv("DATABASE_DRIVER", "sqlite"),
+		DefaultLocale:   getEnv("DEFAULT_LOCALE", "en"),
+		SupportedLocales: strings.Split(getEnv("SUPPORTED_LOCALES", "en,id"), ","),
+	}
+
+	interval, err := time.ParseDuration(getEnv("SIMILARITY_REBUILD_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SIMILARITY_REBUILD_INTERVAL: %w", err)
+	}
+	config.SimilarityRebuildInterval = interval
+
+	refresh, err := time.ParseDuration(getEnv("CATALOG_REFRESH_INTERVAL", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CATALOG_REFRESH_INTERVAL: %w", err)
+	}
+	config.CatalogRefreshInterval = refresh
+
+	reload, err := time.ParseDuration(getEnv("SETTINGS_RELOAD_INTERVAL", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SETTINGS_RELOAD_INTERVAL: %w", err)
 	}
+	config.SettingsReloadInterval = reload
 
 	// Validate required fields
 	if config.JWTSecret == "your-super-secret-jwt-key-change-in-production" && config.Environment == "production" {
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"fmt"
+
+	"perfume-website/internal/models"
+)
+
+// Migrate applies additive schema changes on top of the imported catalog schema.
+// The core tables are created by the import script, so only new columns and
+// tables are handled here.
+func (d *Database) Migrate() error {
+	migrator := d.DB.Migrator()
+
+	// Weighted accords: perfume_aromas.strength (existing rows count as full strength)
+	if !migrator.HasColumn(&models.PerfumeAroma{}, "Strength") {
+		if err := migrator.AddColumn(&models.PerfumeAroma{}, "Strength"); err != nil {
+			return fmt.Errorf("failed to add perfume_aromas.strength: %w", err)
+		}
+	}
+
+	// Localized catalog text
+	if err := d.DB.AutoMigrate(&models.Translation{}); err != nil {
+		return fmt.Errorf("failed to migrate translations: %w", err)
+	}
+
+	// Runtime-configurable recommendation weights
+	if err := d.DB.AutoMigrate(&models.RecommendationSettingsRecord{}); err != nil {
+		return fmt.Errorf("failed to migrate recommendation settings: %w", err)
+	}
+
+	// A/B experiments on the recommendation endpoints
+	if err := d.DB.AutoMigrate(&models.Experiment{}, &models.ExperimentAssignment{}, &models.ExperimentExposure{}, &models.ExperimentEvent{}); err != nil {
+		return fmt.Errorf("failed to migrate experiments: %w", err)
+	}
+
+	// Served recommendations with their impressions and clicks
+	if err := d.DB.AutoMigrate(&models.RecommendationRecord{}, &models.PerfumeScore{}, &models.RecommendationEvent{}, &models.FeedbackRecord{}); err != nil {
+		return fmt.Errorf("failed to migrate recommendation history: %w", err)
+	}
+
+	// Adaptive quiz sessions
+	if err := d.DB.AutoMigrate(&models.QuizSession{}); err != nil {
+		return fmt.Errorf("failed to migrate quiz sessions: %w", err)
+	}
+
+	return nil
+}
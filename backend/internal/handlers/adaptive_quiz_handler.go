@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"perfume-website/internal/middleware"
+	"perfume-website/internal/models"
+	"perfume-website/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdaptiveQuizHandler struct {
+	adaptiveQuizService *services.AdaptiveQuizService
+	translationService  services.TranslationService
+}
+
+func NewAdaptiveQuizHandler(adaptiveQuizService *services.AdaptiveQuizService, translationService services.TranslationService) *AdaptiveQuizHandler {
+	return &AdaptiveQuizHandler{
+		adaptiveQuizService: adaptiveQuizService,
+		translationService:  translationService,
+	}
+}
+
+// StartSession opens an adaptive quiz and returns its first question. The body
+// is optional: the same options as /api/quiz/recommendations, except gift mode.
+func (h *AdaptiveQuizHandler) StartSession(c *gin.Context) {
+	var req models.AdvancedRecommendationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.adaptiveQuizService.Start(req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, h.localize(c, response))
+}
+
+// AnswerQuestion answers the session's pending question and returns the next
+// one, or the recommendations once the top results are stable
+func (h *AdaptiveQuizHandler) AnswerQuestion(c *gin.Context) {
+	var answer models.QuizAnswer
+	if err := c.ShouldBindJSON(&answer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.adaptiveQuizService.Answer(c.Param("id"), answer)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, h.localize(c, response))
+}
+
+func (h *AdaptiveQuizHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrQuizSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrQuizSessionCompleted), errors.Is(err, services.ErrQuizSessionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidQuizAnswer), errors.Is(err, services.ErrInvalidQuizRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *AdaptiveQuizHandler) localize(c *gin.Context, response *models.QuizSessionResponse) *models.QuizSessionResponse {
+	if recommendations := response.Recommendations; recommendations != nil {
+		locales := middleware.GetLocalesFromContext(c)
+		for i := range recommendations.Results {
+			h.translationService.LocalizePerfume(&recommendations.Results[i].Perfume, locales)
+		}
+		for i := range recommendations.Alternatives {
+			h.translationService.LocalizePerfume(&recommendations.Alternatives[i], locales)
+		}
+		h.translationService.LocalizePersonalityAnalysis(&recommendations.PersonalityAnalysis, locales)
+	}
+	return response
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"perfume-website/internal/middleware"
 	"perfume-website/internal/models"
 	"perfume-website/internal/services"
 
@@ -11,12 +12,14 @@ import (
 )
 
 type AromaHandler struct {
-	aromaService services.AromaService
+	aromaService       services.AromaService
+	translationService services.TranslationService
 }
 
-func NewAromaHandler(aromaService services.AromaService) *AromaHandler {
+func NewAromaHandler(aromaService services.AromaService, translationService services.TranslationService) *AromaHandler {
 	return &AromaHandler{
-		aromaService: aromaService,
+		aromaService:       aromaService,
+		translationService: translationService,
 	}
 }
 
@@ -28,7 +31,24 @@ func (h *AromaHandler) GetAllAromas(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, aromas)
+	c.JSON(http.StatusOK, h.translationService.LocalizeAromaTags(aromas, middleware.GetLocalesFromContext(c)))
+}
+
+// GetAromaStats returns aroma tag usage statistics and the co-occurrence matrix
+func (h *AromaHandler) GetAromaStats(c *gin.Context) {
+	minCount, err := strconv.ParseInt(c.DefaultQuery("min_count", "1"), 10, 64)
+	if err != nil || minCount < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_count"})
+		return
+	}
+
+	stats, err := h.aromaService.GetAromaStats(minCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
 }
 
 // GetAroma returns a single aroma tag by ID
@@ -45,7 +65,8 @@ func (h *AromaHandler) GetAroma(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, aroma)
+	localized := h.translationService.LocalizeAromaTags([]models.AromaTag{*aroma}, middleware.GetLocalesFromContext(c))
+	c.JSON(http.StatusOK, localized[0])
 }
 
 // CreateAroma creates a new aroma tag (admin only)
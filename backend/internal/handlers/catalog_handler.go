@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"perfume-website/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogHandler struct {
+	catalogService *services.CatalogService
+}
+
+func NewCatalogHandler(catalogService *services.CatalogService) *CatalogHandler {
+	return &CatalogHandler{
+		catalogService: catalogService,
+	}
+}
+
+// RebuildCatalog reloads the in-memory catalog the recommenders read from,
+// e.g. right after a CSV import (admin only)
+func (h *CatalogHandler) RebuildCatalog(c *gin.Context) {
+	snapshot, err := h.catalogService.Rebuild()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"perfumes": len(snapshot.Perfumes),
+		"built_at": snapshot.BuiltAt,
+	})
+}
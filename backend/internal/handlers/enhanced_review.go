@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"perfume-website/internal/middleware"
 	"perfume-website/internal/models"
 	"perfume-website/internal/services"
 
@@ -16,13 +18,15 @@ import (
 type EnhancedReviewHandler struct {
 	service  *services.EnhancedReviewService
 	validator *validator.Validate
+	experimentService *services.ExperimentService
 }
 
 // NewEnhancedReviewHandler creates a new enhanced review handler
-func NewEnhancedReviewHandler(service *services.EnhancedReviewService) *EnhancedReviewHandler {
+func NewEnhancedReviewHandler(service *services.EnhancedReviewService, experimentService *services.ExperimentService) *EnhancedReviewHandler {
 	return &EnhancedReviewHandler{
 		service:  service,
 		validator: validator.New(),
+		experimentService: experimentService,
 	}
 }
 
@@ -72,6 +76,11 @@ func (h *EnhancedReviewHandler) CreateEnhancedReview(c *gin.Context) {
 		return
 	}
 
+	// A review counts as a conversion for any experiment the reviewer is in
+	if _, err := h.experimentService.RecordEvent(middleware.GetAnonymousID(c), models.ExperimentEventReview, uint(review.PerfumeID)); err != nil {
+		log.Printf("Failed to record experiment review event: %v", err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"message": "Review created successfully",
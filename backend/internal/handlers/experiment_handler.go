@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"perfume-website/internal/middleware"
+	"perfume-website/internal/models"
+	"perfume-website/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExperimentHandler struct {
+	experimentService *services.ExperimentService
+}
+
+func NewExperimentHandler(experimentService *services.ExperimentService) *ExperimentHandler {
+	return &ExperimentHandler{
+		experimentService: experimentService,
+	}
+}
+
+// RecordEvent records an outcome (click, review) for the calling visitor's experiments
+func (h *ExperimentHandler) RecordEvent(c *gin.Context) {
+	var req models.RecordExperimentEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recorded, err := h.experimentService.RecordEvent(middleware.GetAnonymousID(c), req.Type, req.PerfumeID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"recorded": recorded})
+}
+
+// ListExperiments returns every experiment (admin only)
+func (h *ExperimentHandler) ListExperiments(c *gin.Context) {
+	experiments, err := h.experimentService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, experiments)
+}
+
+// CreateExperiment defines a new experiment as a draft (admin only)
+func (h *ExperimentHandler) CreateExperiment(c *gin.Context) {
+	var req models.CreateExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	createdBy := ""
+	if admin, exists := c.Get("admin"); exists {
+		if a, ok := admin.(*models.Admin); ok {
+			createdBy = a.Username
+		}
+	}
+
+	experiment, err := h.experimentService.Create(req, createdBy)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, experiment)
+}
+
+// UpdateExperimentStatus starts or stops an experiment (admin only)
+func (h *ExperimentHandler) UpdateExperimentStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid experiment ID"})
+		return
+	}
+
+	var req models.UpdateExperimentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	experiment, err := h.experimentService.SetStatus(uint(id), req.Status)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, experiment)
+}
+
+// GetExperimentReport returns per-variant conversion with confidence intervals (admin only)
+func (h *ExperimentHandler) GetExperimentReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid experiment ID"})
+		return
+	}
+
+	report, err := h.experimentService.Report(uint(id))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *ExperimentHandler) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrExperimentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Experiment not found"})
+	case errors.Is(err, services.ErrInvalidExperiment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrExperimentConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
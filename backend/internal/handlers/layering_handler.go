@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"perfume-website/internal/middleware"
+	"perfume-website/internal/models"
+	"perfume-website/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LayeringHandler struct {
+	layeringService    *services.LayeringService
+	translationService services.TranslationService
+}
+
+func NewLayeringHandler(layeringService *services.LayeringService, translationService services.TranslationService) *LayeringHandler {
+	return &LayeringHandler{
+		layeringService:    layeringService,
+		translationService: translationService,
+	}
+}
+
+// RecommendLayering suggests perfumes to layer with a perfume, or pairs that
+// create a vibe, with a preview of the combined pyramid
+func (h *LayeringHandler) RecommendLayering(c *gin.Context) {
+	var req models.LayeringRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.layeringService.Recommend(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPerfumeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Perfume not found"})
+		case errors.Is(err, services.ErrInvalidLayeringRequest):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	locales := middleware.GetLocalesFromContext(c)
+	for i := range response.Pairings {
+		h.translationService.LocalizePerfume(&response.Pairings[i].Perfume, locales)
+		h.translationService.LocalizePerfume(&response.Pairings[i].Partner, locales)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
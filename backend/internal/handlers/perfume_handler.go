@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"perfume-website/internal/middleware"
 	"perfume-website/internal/models"
 	"perfume-website/internal/services"
 
@@ -12,12 +15,18 @@ import (
 )
 
 type PerfumeHandler struct {
-	perfumeService services.PerfumeService
+	perfumeService     services.PerfumeService
+	translationService services.TranslationService
+	experimentService  *services.ExperimentService
+	historyService     *services.RecommendationHistoryService
 }
 
-func NewPerfumeHandler(perfumeService services.PerfumeService) *PerfumeHandler {
+func NewPerfumeHandler(perfumeService services.PerfumeService, translationService services.TranslationService, experimentService *services.ExperimentService, historyService *services.RecommendationHistoryService) *PerfumeHandler {
 	return &PerfumeHandler{
-		perfumeService: perfumeService,
+		perfumeService:     perfumeService,
+		translationService: translationService,
+		experimentService:  experimentService,
+		historyService:     historyService,
 	}
 }
 
@@ -43,6 +52,11 @@ func (h *PerfumeHandler) GetAllPerfumes(c *gin.Context) {
 	search := c.Query("search")
 	brand := c.Query("brand")
 	aroma := c.Query("aroma")
+	// exclude_ingredients may be repeated or comma-separated
+	var excludeIngredients []string
+	for _, value := range c.QueryArray("exclude_ingredients") {
+		excludeIngredients = append(excludeIngredients, strings.Split(value, ",")...)
+	}
 
 	// Validate pagination
 	if page < 1 {
@@ -52,14 +66,16 @@ func (h *PerfumeHandler) GetAllPerfumes(c *gin.Context) {
 		limit = 12
 	}
 
-	perfumes, total, err := h.perfumeService.GetPerfumesWithPagination(page, limit, search, brand, aroma)
+	perfumes, total, excluded, err := h.perfumeService.GetPerfumesWithPagination(page, limit, search, brand, aroma, excludeIngredients)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	locales := middleware.GetLocalesFromContext(c)
 	var responses []models.PerfumeResponse
 	for _, perfume := range perfumes {
+		h.translationService.LocalizePerfume(&perfume, locales)
 		responses = append(responses, models.PerfumeResponse{
 			ID:           perfume.ID,
 			Name:         perfume.Name,
@@ -71,6 +87,7 @@ func (h *PerfumeHandler) GetAllPerfumes(c *gin.Context) {
 			Price:        perfume.Price,
 			ImageURL:     perfume.ImageURL,
 			AromaTags:    perfume.AromaTags,
+			Accords:      perfume.AccordResponses(),
 			Notes:        perfume.Notes,
 			CreatedAt:    perfume.CreatedAt,
 			UpdatedAt:    perfume.UpdatedAt,
@@ -90,6 +107,7 @@ func (h *PerfumeHandler) GetAllPerfumes(c *gin.Context) {
 			"has_next":     page < totalPages,
 			"has_prev":     page > 1,
 		},
+		"excluded_by_ingredients": excluded,
 	})
 }
 
@@ -106,6 +124,7 @@ func (h *PerfumeHandler) GetPerfume(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Perfume not found"})
 		return
 	}
+	h.translationService.LocalizePerfume(perfume, middleware.GetLocalesFromContext(c))
 
 	response := models.PerfumeResponse{
 		ID:           perfume.ID,
@@ -118,6 +137,7 @@ func (h *PerfumeHandler) GetPerfume(c *gin.Context) {
 		Price:        perfume.Price,
 		ImageURL:     perfume.ImageURL,
 		AromaTags:    perfume.AromaTags,
+		Accords:      perfume.AccordResponses(),
 		Notes:        perfume.Notes,
 		CreatedAt:    perfume.CreatedAt,
 		UpdatedAt:    perfume.UpdatedAt,
@@ -135,6 +155,10 @@ func (h *PerfumeHandler) CreatePerfume(c *gin.Context) {
 	}
 
 	if err := h.perfumeService.CreatePerfume(&perfume); err != nil {
+		if errors.Is(err, services.ErrInvalidAccords) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -158,6 +182,10 @@ func (h *PerfumeHandler) UpdatePerfume(c *gin.Context) {
 
 	perfume.ID = uint(id)
 	if err := h.perfumeService.UpdatePerfume(&perfume); err != nil {
+		if errors.Is(err, services.ErrInvalidAccords) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -165,6 +193,39 @@ func (h *PerfumeHandler) UpdatePerfume(c *gin.Context) {
 	c.JSON(http.StatusOK, perfume)
 }
 
+// UpdatePerfumeAccords replaces the weighted aroma tags of a perfume (admin only)
+func (h *PerfumeHandler) UpdatePerfumeAccords(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid perfume ID"})
+		return
+	}
+
+	var req models.UpdateAccordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	perfume, err := h.perfumeService.UpdatePerfumeAccords(uint(id), req.Accords)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPerfumeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Perfume not found"})
+		case errors.Is(err, services.ErrInvalidAccords):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"perfume_id": perfume.ID,
+		"accords":    perfume.AccordResponses(),
+	})
+}
+
 // DeletePerfume deletes a perfume (admin only)
 func (h *PerfumeHandler) DeletePerfume(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -189,12 +250,55 @@ func (h *PerfumeHandler) RecommendPerfumes(c *gin.Context) {
 		return
 	}
 
-	response, err := h.perfumeService.RecommendPerfumes(req.Aromas)
+	// History hashes the request as sent, before the experiment picks its strategy
+	clientReq := req
+
+	// Requests that pin a strategy stay out of experiments; the others use their variant's
+	var assignment *services.VariantAssignment
+	if req.Strategy == "" {
+		var err error
+		assignment, err = h.experimentService.Assign(models.ExperimentEndpointRecommend, middleware.GetAnonymousID(c))
+		if err != nil {
+			log.Printf("Serving recommendations outside the experiment: %v", err)
+		}
+		if assignment != nil {
+			req.Strategy = assignment.Variant.Strategy
+		}
+	}
+
+	response, err := h.perfumeService.RecommendPerfumes(req)
 	if err != nil {
+		if errors.Is(err, services.ErrUnknownStrategy) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	locales := middleware.GetLocalesFromContext(c)
+	for i := range response.Results {
+		h.translationService.LocalizePerfumeResponse(&response.Results[i].Perfume, locales)
+	}
+
+	if assignment != nil {
+		ids := make([]uint, len(response.Results))
+		for i, result := range response.Results {
+			ids[i] = result.Perfume.ID
+		}
+		if err := h.experimentService.LogExposure(assignment, ids); err != nil {
+			log.Printf("Failed to log experiment exposure: %v", err)
+		}
+		response.Experiment = assignment.Info()
+	}
+
+	record, err := h.historyService.RecordRecommend(middleware.GetSessionID(c), clientReq, response, assignment)
+	if err != nil {
+		log.Printf("Failed to record recommendation history: %v", err)
+	} else if record != nil {
+		response.RecommendationID = record.ID
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 
+	"perfume-website/internal/middleware"
 	"perfume-website/internal/models"
 	"perfume-website/internal/services"
 
@@ -10,12 +13,18 @@ import (
 )
 
 type QuizHandler struct {
-	quizService *services.QuizService
+	quizService        *services.QuizService
+	translationService services.TranslationService
+	experimentService  *services.ExperimentService
+	historyService     *services.RecommendationHistoryService
 }
 
-func NewQuizHandler(quizService *services.QuizService) *QuizHandler {
+func NewQuizHandler(quizService *services.QuizService, translationService services.TranslationService, experimentService *services.ExperimentService, historyService *services.RecommendationHistoryService) *QuizHandler {
 	return &QuizHandler{
-		quizService: quizService,
+		quizService:        quizService,
+		translationService: translationService,
+		experimentService:  experimentService,
+		historyService:     historyService,
 	}
 }
 
@@ -35,8 +44,40 @@ func (h *QuizHandler) GetAdvancedRecommendations(c *gin.Context) {
 		req.MaxResults = 6
 	}
 
-	response, err := h.quizService.GetAdvancedRecommendations(req)
+	// History hashes the request as sent, before feedback and experiments change it
+	clientReq := req
+
+	// Apply the session's feedback on earlier recommendations
+	sessionID := middleware.GetSessionID(c)
+	feedback, err := h.historyService.ApplyFeedback(sessionID, &req)
+	if err != nil {
+		log.Printf("Serving quiz recommendations without feedback: %v", err)
+	}
+
+	// Visitors in a running experiment are scored with their variant's algorithm
+	// and settings, unless the request picks an algorithm itself
+	var assignment *services.VariantAssignment
+	if req.Algorithm == "" {
+		assignment, err = h.experimentService.Assign(models.ExperimentEndpointQuiz, middleware.GetAnonymousID(c))
+		if err != nil {
+			log.Printf("Serving quiz recommendations outside the experiment: %v", err)
+		}
+		if assignment != nil {
+			req.Algorithm = assignment.Variant.Strategy
+		}
+	}
+	settings, err := h.experimentService.QuizSettings(assignment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.quizService.GetAdvancedRecommendationsWithSettings(req, settings)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidQuizRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to generate recommendations",
 			"details": err.Error(),
@@ -44,6 +85,35 @@ func (h *QuizHandler) GetAdvancedRecommendations(c *gin.Context) {
 		return
 	}
 
+	locales := middleware.GetLocalesFromContext(c)
+	for i := range response.Results {
+		h.translationService.LocalizePerfume(&response.Results[i].Perfume, locales)
+	}
+	for i := range response.Alternatives {
+		h.translationService.LocalizePerfume(&response.Alternatives[i], locales)
+	}
+	h.translationService.LocalizePersonalityAnalysis(&response.PersonalityAnalysis, locales)
+
+	if assignment != nil {
+		ids := make([]uint, len(response.Results))
+		for i, result := range response.Results {
+			ids[i] = result.Perfume.ID
+		}
+		if err := h.experimentService.LogExposure(assignment, ids); err != nil {
+			log.Printf("Failed to log experiment exposure: %v", err)
+		}
+		response.Experiment = assignment.Info()
+	}
+
+	response.Feedback = feedback
+
+	record, err := h.historyService.RecordQuiz(sessionID, clientReq, response, assignment)
+	if err != nil {
+		log.Printf("Failed to record recommendation history: %v", err)
+	} else if record != nil {
+		response.RecommendationID = record.ID
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -86,6 +156,6 @@ func (h *QuizHandler) GetQuizStats(c *gin.Context) {
 
 // GetPersonalityTypes returns available personality types and their descriptions
 func (h *QuizHandler) GetPersonalityTypes(c *gin.Context) {
-	personalities := h.quizService.GetPersonalityTypes()
+	personalities := h.translationService.LocalizePersonalityTypes(h.quizService.GetPersonalityTypes(), middleware.GetLocalesFromContext(c))
 	c.JSON(http.StatusOK, personalities)
 }
\ No newline at end of file
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"perfume-website/internal/middleware"
+	"perfume-website/internal/models"
+	"perfume-website/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecommendationHistoryHandler struct {
+	historyService    *services.RecommendationHistoryService
+	experimentService *services.ExperimentService
+}
+
+func NewRecommendationHistoryHandler(historyService *services.RecommendationHistoryService, experimentService *services.ExperimentService) *RecommendationHistoryHandler {
+	return &RecommendationHistoryHandler{
+		historyService:    historyService,
+		experimentService: experimentService,
+	}
+}
+
+// RecordEvent records an impression or click on a perfume recommended to the
+// X-Session-ID session
+func (h *RecommendationHistoryHandler) RecordEvent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recommendation ID"})
+		return
+	}
+
+	var req models.RecordRecommendationEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.historyService.RecordEvent(middleware.GetSessionID(c), uint(id), req.Type, req.PerfumeID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrRecommendationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recommendation not found"})
+		case errors.Is(err, services.ErrInvalidRecommendationEvent):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// A click on a recommendation is also an outcome for the visitor's experiments
+	if req.Type == models.RecommendationEventClick {
+		if _, err := h.experimentService.RecordEvent(middleware.GetAnonymousID(c), models.ExperimentEventClick, req.PerfumeID); err != nil {
+			log.Printf("Failed to record experiment click event: %v", err)
+		}
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RecordFeedback stores a thumbs up/down, "not interested" or "purchased" on a
+// perfume recommended to the X-Session-ID session; the session's later quiz
+// recommendations take it into account
+func (h *RecommendationHistoryHandler) RecordFeedback(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recommendation ID"})
+		return
+	}
+
+	var req models.RecommendationFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	feedback, err := h.historyService.RecordFeedback(middleware.GetSessionID(c), uint(id), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRecommendationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Recommendation not found"})
+		case errors.Is(err, services.ErrInvalidFeedback):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, feedback)
+}
+
+// GetHistory returns the recommendations served to the X-Session-ID session, newest first
+func (h *RecommendationHistoryHandler) GetHistory(c *gin.Context) {
+	sessionID := middleware.GetSessionID(c)
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A session ID is required (X-Session-ID header or session_id parameter)"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	records, err := h.historyService.History(sessionID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session_id": sessionID, "recommendations": records})
+}
+
+// GetUnclickedPerfumes lists perfumes that keep being recommended but are never clicked (admin only)
+func (h *RecommendationHistoryHandler) GetUnclickedPerfumes(c *gin.Context) {
+	minRecommended, _ := strconv.Atoi(c.DefaultQuery("min_recommended", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	perfumes, err := h.historyService.UnclickedPerfumes(minRecommended, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, perfumes)
+}
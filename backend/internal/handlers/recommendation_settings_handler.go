@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"perfume-website/internal/models"
+	"perfume-website/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecommendationSettingsHandler struct {
+	settingsService *services.RecommendationSettingsService
+}
+
+func NewRecommendationSettingsHandler(settingsService *services.RecommendationSettingsService) *RecommendationSettingsHandler {
+	return &RecommendationSettingsHandler{
+		settingsService: settingsService,
+	}
+}
+
+// GetSettings returns the active recommendation weights and thresholds (admin only)
+func (h *RecommendationSettingsHandler) GetSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"settings": h.settingsService.Get(),
+		"defaults": models.DefaultRecommendationSettings(),
+	})
+}
+
+// UpdateSettings saves new weights and thresholds and applies them immediately (admin only).
+// Fields left out of the body keep their current value.
+func (h *RecommendationSettingsHandler) UpdateSettings(c *gin.Context) {
+	settings := h.settingsService.Current()
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedBy := ""
+	if admin, exists := c.Get("admin"); exists {
+		if a, ok := admin.(*models.Admin); ok {
+			updatedBy = a.Username
+		}
+	}
+
+	response, err := h.settingsService.Update(settings, updatedBy)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSettings) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
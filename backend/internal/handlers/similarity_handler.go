@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"perfume-website/internal/middleware"
+	"perfume-website/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SimilarityHandler struct {
+	similarityService  *services.ItemSimilarityService
+	translationService services.TranslationService
+}
+
+func NewSimilarityHandler(similarityService *services.ItemSimilarityService, translationService services.TranslationService) *SimilarityHandler {
+	return &SimilarityHandler{
+		similarityService:  similarityService,
+		translationService: translationService,
+	}
+}
+
+// GetAlsoLoved returns perfumes loved by reviewers who loved the given perfume
+func (h *SimilarityHandler) GetAlsoLoved(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid perfume ID"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(services.DefaultAlsoLovedLimit)))
+
+	response, err := h.similarityService.AlsoLoved(uint(id), limit)
+	if err != nil {
+		if errors.Is(err, services.ErrPerfumeNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Perfume not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	locales := middleware.GetLocalesFromContext(c)
+	for i := range response.Results {
+		h.translationService.LocalizePerfume(&response.Results[i].Perfume, locales)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAlternatives returns cheaper perfumes that smell like the given perfume,
+// optionally capped by the max_price query parameter
+func (h *SimilarityHandler) GetAlternatives(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid perfume ID"})
+		return
+	}
+	maxPrice := 0.0
+	if raw := c.Query("max_price"); raw != "" {
+		maxPrice, err = strconv.ParseFloat(raw, 64)
+		if err != nil || maxPrice <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_price must be a positive number"})
+			return
+		}
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(services.DefaultAlternativesLimit)))
+
+	response, err := h.similarityService.Alternatives(uint(id), maxPrice, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrPerfumeNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Perfume not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	locales := middleware.GetLocalesFromContext(c)
+	for i := range response.Results {
+		h.translationService.LocalizePerfume(&response.Results[i].Perfume, locales)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
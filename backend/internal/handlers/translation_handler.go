@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"perfume-website/internal/models"
+	"perfume-website/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TranslationHandler struct {
+	translationService services.TranslationService
+}
+
+func NewTranslationHandler(translationService services.TranslationService) *TranslationHandler {
+	return &TranslationHandler{
+		translationService: translationService,
+	}
+}
+
+// GetTranslations lists translations, optionally filtered by entity_type and locale (admin only)
+func (h *TranslationHandler) GetTranslations(c *gin.Context) {
+	entityType := models.TranslationEntity(c.Query("entity_type"))
+	translations, err := h.translationService.ListTranslations(entityType, c.Query("locale"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, translations)
+}
+
+// UpsertTranslation creates or replaces a translation (admin only)
+func (h *TranslationHandler) UpsertTranslation(c *gin.Context) {
+	var req models.UpsertTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translation, err := h.translationService.UpsertTranslation(req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTranslation) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, translation)
+}
+
+// DeleteTranslation deletes a translation (admin only)
+func (h *TranslationHandler) DeleteTranslation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid translation ID"})
+		return
+	}
+
+	if err := h.translationService.DeleteTranslation(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Translation not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Translation deleted successfully"})
+}
+
+// GetMissingTranslations reports untranslated catalog text for a locale (admin only)
+func (h *TranslationHandler) GetMissingTranslations(c *gin.Context) {
+	locale := c.Query("locale")
+	if locale == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "locale is required"})
+		return
+	}
+
+	report, err := h.translationService.GetMissingTranslations(locale, models.TranslationEntity(c.Query("entity_type")))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTranslation) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"perfume-website/internal/middleware"
+	"perfume-website/internal/models"
+	"perfume-website/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WardrobeHandler struct {
+	wardrobeService    *services.WardrobeService
+	translationService services.TranslationService
+}
+
+func NewWardrobeHandler(wardrobeService *services.WardrobeService, translationService services.TranslationService) *WardrobeHandler {
+	return &WardrobeHandler{
+		wardrobeService:    wardrobeService,
+		translationService: translationService,
+	}
+}
+
+// GetWardrobeGaps profiles a perfume collection and suggests perfumes for the
+// seasons, occasions, aroma families and sillage levels it covers least
+func (h *WardrobeHandler) GetWardrobeGaps(c *gin.Context) {
+	var req models.WardrobeGapsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.wardrobeService.Gaps(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPerfumeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidWardrobeRequest):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	locales := middleware.GetLocalesFromContext(c)
+	for i := range response.Gaps {
+		for j := range response.Gaps[i].Picks {
+			h.translationService.LocalizePerfume(&response.Gaps[i].Picks[j].Perfume, locales)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
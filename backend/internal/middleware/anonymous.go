@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnonymousIDHeader identifies a visitor across requests without an account.
+// Clients store the ID the server issues and send it back on every request.
+const AnonymousIDHeader = "X-Anonymous-ID"
+
+const anonymousIDContextKey = "anonymous_id"
+
+var anonymousIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8,64}$`)
+
+// AnonymousIDMiddleware reads the visitor's anonymous ID, issuing a new one when
+// it is missing or malformed, and echoes it in the response header
+func AnonymousIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(AnonymousIDHeader)
+		if !anonymousIDPattern.MatchString(id) {
+			id = newAnonymousID()
+		}
+		c.Set(anonymousIDContextKey, id)
+		c.Header(AnonymousIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetAnonymousID returns the visitor's anonymous ID, or "" outside the middleware
+func GetAnonymousID(c *gin.Context) string {
+	return c.GetString(anonymousIDContextKey)
+}
+
+func newAnonymousID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"perfume-website/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const localesContextKey = "locales"
+
+// LocaleMiddleware resolves the locale fallback chain from ?lang= and
+// Accept-Language and stores it in the request context
+func LocaleMiddleware(translationService services.TranslationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locales := translationService.ResolveLocales(c.Query("lang"), c.GetHeader("Accept-Language"))
+		c.Set(localesContextKey, locales)
+		if len(locales) > 0 {
+			c.Header("Content-Language", locales[0])
+		}
+		c.Next()
+	}
+}
+
+// GetLocalesFromContext returns the locale fallback chain for the request
+func GetLocalesFromContext(c *gin.Context) []string {
+	if locales, exists := c.Get(localesContextKey); exists {
+		if chain, ok := locales.([]string); ok {
+			return chain
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// SessionIDHeader carries the client-chosen session that recommendation
+// history is grouped by
+const SessionIDHeader = "X-Session-ID"
+
+// GetSessionID returns the client-supplied session ID from the header or the
+// session_id query parameter, or "" when it is missing or malformed
+func GetSessionID(c *gin.Context) string {
+	id := c.GetHeader(SessionIDHeader)
+	if id == "" {
+		id = c.Query("session_id")
+	}
+	if !anonymousIDPattern.MatchString(id) {
+		return ""
+	}
+	return id
+}
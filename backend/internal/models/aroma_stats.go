@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// AromaTagStats represents catalog usage figures for a single aroma tag
+type AromaTagStats struct {
+	AromaTagID    uint    `json:"aroma_tag_id"`
+	Slug          string  `json:"slug"`
+	Name          string  `json:"name"`
+	PerfumeCount  int64   `json:"perfume_count"`
+	Share         float64 `json:"share"` // fraction of the catalog carrying this tag
+	AveragePrice  float64 `json:"average_price"`
+	AverageRating float64 `json:"average_rating"`
+	ReviewCount   int64   `json:"review_count"`
+}
+
+// AromaCooccurrence represents how often two aroma tags appear on the same perfume
+type AromaCooccurrence struct {
+	AromaTagID      uint    `json:"aroma_tag_id"`
+	Slug            string  `json:"slug"`
+	OtherAromaTagID uint    `json:"other_aroma_tag_id"`
+	OtherSlug       string  `json:"other_slug"`
+	Count           int64   `json:"count"`
+	Support         float64 `json:"support"` // P(A and B)
+	Lift            float64 `json:"lift"`    // P(A and B) / (P(A) * P(B))
+}
+
+// AromaStatsResponse is the payload for GET /api/aromas/stats
+type AromaStatsResponse struct {
+	TotalPerfumes int64               `json:"total_perfumes"`
+	Tags          []AromaTagStats     `json:"tags"`
+	Cooccurrences []AromaCooccurrence `json:"cooccurrences"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+}
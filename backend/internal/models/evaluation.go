@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// EvaluationCase is a saved quiz replayed offline, with the perfumes its author
+// rated highly in reviews as ground truth
+type EvaluationCase struct {
+	Quiz     PersonalityQuiz
+	Reviewer string        // lower-cased email, or name for quizzes without one
+	Relevant map[uint]bool // perfume IDs the reviewer rated highly
+}
+
+// StrategyMetrics are the offline metrics of one scoring strategy, averaged over the evaluated quizzes
+type StrategyMetrics struct {
+	Strategy  string  `json:"strategy"`
+	Cases     int     `json:"cases"`
+	Rejected  int     `json:"rejected"` // requests the strategy refused, scored as empty lists
+	Precision float64 `json:"precision_at_k"`
+	Recall    float64 `json:"recall_at_k"`
+	NDCG      float64 `json:"ndcg_at_k"`
+	Coverage  float64 `json:"coverage"`  // share of the catalog recommended to anyone
+	Diversity float64 `json:"diversity"` // mean pairwise dissimilarity within a list (0-1)
+}
+
+// EvaluationReport compares scoring strategies on the same replayed quizzes
+type EvaluationReport struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	K           int               `json:"k"`
+	MinRating   int               `json:"min_rating"`
+	Quizzes     int               `json:"quizzes"` // saved quizzes
+	Cases       int               `json:"cases"`   // quizzes whose author rated at least one perfume highly
+	CatalogSize int               `json:"catalog_size"`
+	Strategies  []StrategyMetrics `json:"strategies"`
+}
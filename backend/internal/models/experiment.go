@@ -0,0 +1,163 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ExperimentEndpoint names a recommendation endpoint that can run experiments
+type ExperimentEndpoint string
+
+const (
+	ExperimentEndpointQuiz      ExperimentEndpoint = "quiz"      // POST /api/quiz/recommendations
+	ExperimentEndpointRecommend ExperimentEndpoint = "recommend" // POST /api/recommend
+)
+
+type ExperimentStatus string
+
+const (
+	ExperimentStatusDraft   ExperimentStatus = "draft"
+	ExperimentStatusRunning ExperimentStatus = "running"
+	ExperimentStatusStopped ExperimentStatus = "stopped"
+)
+
+// Outcome events attributed to the variants a visitor was exposed to
+const (
+	ExperimentEventClick  = "click"
+	ExperimentEventReview = "review"
+)
+
+// Experiment splits the traffic of one endpoint between variants. At most one
+// experiment per endpoint runs at a time.
+type Experiment struct {
+	ID        uint                `json:"id" gorm:"primaryKey"`
+	Key       string              `json:"key" gorm:"not null;size:100;uniqueIndex"` // also salts the assignment hash
+	Name      string              `json:"name" gorm:"not null;size:255"`
+	Endpoint  ExperimentEndpoint  `json:"endpoint" gorm:"not null;size:20;index"`
+	Status    ExperimentStatus    `json:"status" gorm:"not null;size:20;default:draft"`
+	Variants  []ExperimentVariant `json:"variants" gorm:"serializer:json;type:text"`
+	StartedAt *time.Time          `json:"started_at"`
+	StoppedAt *time.Time          `json:"stopped_at"`
+	CreatedBy string              `json:"created_by" gorm:"size:255"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// ExperimentVariant is one arm of an experiment. The first variant is the
+// control the others are compared with.
+type ExperimentVariant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"` // relative share of traffic
+
+	// Settings are recommendation settings decoded on top of the active ones
+	// for quiz experiments, e.g. {"weights": {"notes": 0.4}}; empty keeps them
+	Settings json.RawMessage `json:"settings,omitempty"`
+	// Strategy is the /api/recommend scoring strategy for recommend
+	// experiments, or the quiz algorithm for quiz experiments; empty keeps the
+	// default
+	Strategy string `json:"strategy,omitempty"`
+}
+
+// Variant returns the variant with the given name
+func (e *Experiment) Variant(name string) (*ExperimentVariant, bool) {
+	for i := range e.Variants {
+		if e.Variants[i].Name == name {
+			return &e.Variants[i], true
+		}
+	}
+	return nil, false
+}
+
+// ExperimentAssignment records the variant a visitor was first assigned, so
+// later changes to variant weights do not move them
+type ExperimentAssignment struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ExperimentID uint      `json:"experiment_id" gorm:"not null;uniqueIndex:idx_experiment_visitor"`
+	AnonymousID  string    `json:"anonymous_id" gorm:"not null;size:64;uniqueIndex:idx_experiment_visitor;index"`
+	Variant      string    `json:"variant" gorm:"not null;size:100"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ExperimentExposure is one response served under a variant
+type ExperimentExposure struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ExperimentID uint      `json:"experiment_id" gorm:"not null;index"`
+	Variant      string    `json:"variant" gorm:"not null;size:100"`
+	AnonymousID  string    `json:"anonymous_id" gorm:"not null;size:64;index"`
+	PerfumeIDs   []uint    `json:"perfume_ids" gorm:"serializer:json;type:text"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ExperimentEvent is an outcome (click, review) by a visitor assigned to a variant
+type ExperimentEvent struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ExperimentID uint      `json:"experiment_id" gorm:"not null;index"`
+	Variant      string    `json:"variant" gorm:"not null;size:100"`
+	AnonymousID  string    `json:"anonymous_id" gorm:"not null;size:64;index"`
+	Type         string    `json:"type" gorm:"not null;size:20"`
+	PerfumeID    uint      `json:"perfume_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreateExperimentRequest defines a new experiment, created as a draft
+type CreateExperimentRequest struct {
+	Key      string              `json:"key" binding:"required"`
+	Name     string              `json:"name" binding:"required"`
+	Endpoint ExperimentEndpoint  `json:"endpoint" binding:"required"`
+	Variants []ExperimentVariant `json:"variants" binding:"required"`
+}
+
+// UpdateExperimentStatusRequest starts or stops an experiment
+type UpdateExperimentStatusRequest struct {
+	Status ExperimentStatus `json:"status" binding:"required"`
+}
+
+// RecordExperimentEventRequest reports an outcome for the calling visitor
+type RecordExperimentEventRequest struct {
+	Type      string `json:"type" binding:"required"` // click, review
+	PerfumeID uint   `json:"perfume_id"`
+}
+
+// ExperimentExposureInfo tells the client which variant served a response
+type ExperimentExposureInfo struct {
+	Key     string `json:"key"`
+	Variant string `json:"variant"`
+}
+
+// VariantStats are exposure and conversion counts for a variant, counted per visitor
+type VariantStats struct {
+	Variant   string
+	Users     int
+	Exposures int
+	Converted map[string]int // event type -> visitors with the event after an exposure
+}
+
+// ConversionStats is the conversion of one variant for one event type with a
+// 95% Wilson score interval, and its difference from the control
+type ConversionStats struct {
+	Converted   int     `json:"converted"`
+	Rate        float64 `json:"rate"`
+	Lower       float64 `json:"ci_lower"`
+	Upper       float64 `json:"ci_upper"`
+	Lift        float64 `json:"lift"` // rate minus the control's rate
+	LiftLower   float64 `json:"lift_ci_lower"`
+	LiftUpper   float64 `json:"lift_ci_upper"`
+	Significant bool    `json:"significant"` // the lift interval excludes zero
+}
+
+// VariantReport is one row of the experiment report
+type VariantReport struct {
+	Variant     string                     `json:"variant"`
+	Control     bool                       `json:"control"`
+	Users       int                        `json:"users"`
+	Exposures   int                        `json:"exposures"`
+	Conversions map[string]ConversionStats `json:"conversions"` // keyed by event type
+}
+
+// ExperimentReport compares the variants of an experiment
+type ExperimentReport struct {
+	Experiment  Experiment      `json:"experiment"`
+	Confidence  float64         `json:"confidence"`
+	Variants    []VariantReport `json:"variants"`
+	GeneratedAt time.Time       `json:"generated_at"`
+}
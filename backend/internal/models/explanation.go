@@ -0,0 +1,19 @@
+package models
+
+const (
+	ExplanationPositive = "positive"
+	ExplanationNegative = "negative"
+)
+
+// Explanation is one fact behind a recommendation score. Code identifies the
+// fact and Template its wording with {param} placeholders, so clients can
+// render their own localized text from Code and Params. Message is the
+// template rendered in English.
+type Explanation struct {
+	Code      string         `json:"code"`
+	Component string         `json:"component"` // score component the fact contributed to
+	Effect    string         `json:"effect"`    // positive or negative
+	Template  string         `json:"template"`
+	Params    map[string]any `json:"params"`
+	Message   string         `json:"message"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+const (
+	SimilaritySourceCommunity = "community" // co-rating patterns in enhanced reviews
+	SimilaritySourceContent   = "content"   // shared accords and notes, used for cold-start perfumes
+)
+
+// ItemRating is one reviewer's overall rating of a perfume
+type ItemRating struct {
+	PerfumeID uint
+	Reviewer  string // lower-cased email, or user name for reviews without one
+	Rating    int
+}
+
+// SimilarPerfume is a perfume related to another one, with where the similarity came from
+type SimilarPerfume struct {
+	Perfume    Perfume `json:"perfume"`
+	Similarity float64 `json:"similarity"` // 0-1
+	Source     string  `json:"source"`
+	CoRaters   int     `json:"co_raters,omitempty"` // reviewers who rated both perfumes
+}
+
+// AlsoLovedResponse lists perfumes loved by the people who loved a perfume
+type AlsoLovedResponse struct {
+	PerfumeID    uint             `json:"perfume_id"`
+	Results      []SimilarPerfume `json:"results"`
+	ModelBuiltAt time.Time        `json:"model_built_at"`
+}
+
+// AlternativePerfume is a cheaper perfume that smells like a reference perfume
+type AlternativePerfume struct {
+	Perfume           Perfume  `json:"perfume"`
+	Similarity        float64  `json:"similarity"` // 0-1, accords and notes
+	SharedNotes       []string `json:"shared_notes"`
+	SharedNotePercent float64  `json:"shared_note_percent"` // of the reference's notes
+	PriceSaving       float64  `json:"price_saving"`
+	SavingPercent     float64  `json:"saving_percent"`
+}
+
+// AlternativesResponse lists budget alternatives to a perfume
+type AlternativesResponse struct {
+	PerfumeID      uint                 `json:"perfume_id"`
+	ReferencePrice float64              `json:"reference_price"`
+	MaxPrice       float64              `json:"max_price"`
+	MinSimilarity  float64              `json:"min_similarity"`
+	Results        []AlternativePerfume `json:"results"`
+}
@@ -0,0 +1,41 @@
+package models
+
+// LayeringRequest asks for perfumes to layer. Give either a perfume to find
+// partners for, or a vibe to build pairs for from the whole catalog.
+type LayeringRequest struct {
+	PerfumeID uint   `json:"perfume_id"`
+	Vibe      string `json:"vibe"`  // confident, elegant, playful, mysterious
+	Limit     int    `json:"limit"` // default: 6, max: 24
+}
+
+// LayeredNote is a note of the combined pyramid and the perfumes it comes from
+type LayeredNote struct {
+	NoteName   string `json:"note_name"`
+	Intensity  int    `json:"intensity"`
+	PerfumeIDs []uint `json:"perfume_ids"`
+}
+
+// LayeredPyramid previews how two layered perfumes develop together
+type LayeredPyramid struct {
+	Top   []LayeredNote `json:"top"`
+	Heart []LayeredNote `json:"heart"`
+	Base  []LayeredNote `json:"base"`
+}
+
+// LayeringPairing is two perfumes worn together. ApplyFirstID is the perfume
+// whose base carries the blend; the other one is sprayed over it.
+type LayeringPairing struct {
+	Perfume        Perfume            `json:"perfume"` // the requested perfume, or the anchor of a vibe pair
+	Partner        Perfume            `json:"partner"`
+	ApplyFirstID   uint               `json:"apply_first_id"`
+	Score          float64            `json:"score"` // 0-1
+	ScoreBreakdown map[string]float64 `json:"score_breakdown"`
+	Pyramid        LayeredPyramid     `json:"pyramid"`
+	Explanations   []Explanation      `json:"explanations"`
+}
+
+type LayeringResponse struct {
+	PerfumeID uint              `json:"perfume_id,omitempty"`
+	Vibe      string            `json:"vibe,omitempty"`
+	Pairings  []LayeringPairing `json:"pairings"`
+}
@@ -25,6 +25,35 @@ type Perfume struct {
 	// Relationships
 	AromaTags []AromaTag `json:"aroma_tags" gorm:"many2many:perfume_aromas;"`
 	Notes      []Note      `json:"notes" gorm:"foreignKey:PerfumeID"`
+	Accords    []PerfumeAroma `json:"accords,omitempty" gorm:"foreignKey:PerfumeID"`
+}
+
+// AccordStrengths maps aroma tag IDs to their strength (0-100) on this perfume.
+// Tags without a loaded accord row count as full strength.
+func (p *Perfume) AccordStrengths() map[uint]int {
+	strengths := make(map[uint]int, len(p.AromaTags))
+	for _, tag := range p.AromaTags {
+		strengths[tag.ID] = MaxAccordStrength
+	}
+	for _, accord := range p.Accords {
+		strengths[accord.AromaTagID] = accord.Strength
+	}
+	return strengths
+}
+
+// AccordResponses returns the perfume's aroma tags together with their strength
+func (p *Perfume) AccordResponses() []AccordResponse {
+	strengths := p.AccordStrengths()
+	accords := make([]AccordResponse, 0, len(p.AromaTags))
+	for _, tag := range p.AromaTags {
+		accords = append(accords, AccordResponse{
+			AromaTagID: tag.ID,
+			Slug:       tag.Slug,
+			Name:       tag.Name,
+			Strength:   strengths[tag.ID],
+		})
+	}
+	return accords
 }
 
 type NoteType string
@@ -60,9 +89,13 @@ type AromaTag struct {
 	Perfumes []Perfume `json:"-" gorm:"many2many:perfume_aromas;"`
 }
 
+// MaxAccordStrength is the strength of an accord that fully dominates a perfume
+const MaxAccordStrength = 100
+
 type PerfumeAroma struct {
 	PerfumeID   uint `json:"perfume_id" gorm:"primaryKey"`
 	AromaTagID  uint `json:"aroma_tag_id" gorm:"primaryKey"`
+	Strength    int  `json:"strength" gorm:"not null;default:100"` // 0-100 scale
 	CreatedAt   time.Time `json:"created_at"`
 
 	// Relationships
@@ -82,21 +115,53 @@ type PerfumeResponse struct {
 	Price        float64          `json:"price"`
 	ImageURL     string           `json:"image_url"`
 	AromaTags    []AromaTag       `json:"aroma_tags"`
+	Accords      []AccordResponse `json:"accords"`
 	Notes        []Note           `json:"notes"`
 	CreatedAt    time.Time        `json:"created_at"`
 	UpdatedAt    time.Time        `json:"updated_at"`
 }
 
+// AccordResponse describes how strongly an aroma tag is expressed in a perfume
+type AccordResponse struct {
+	AromaTagID uint   `json:"aroma_tag_id"`
+	Slug       string `json:"slug"`
+	Name       string `json:"name"`
+	Strength   int    `json:"strength"`
+}
+
+// AccordInput is a single aroma tag strength submitted by an admin. Strength
+// is a pointer so that a missing strength is rejected rather than stored as 0.
+type AccordInput struct {
+	AromaTagID uint `json:"aroma_tag_id" binding:"required"`
+	Strength   *int `json:"strength" binding:"required,min=0,max=100"`
+}
+
+type UpdateAccordsRequest struct {
+	Accords []AccordInput `json:"accords" binding:"required,dive"`
+}
+
 type RecommendationRequest struct {
-	Aromas []string `json:"aromas" binding:"required"`
+	Aromas   []string `json:"aromas" binding:"required"`
+	Limit    int      `json:"limit"`    // default: 6, max: 50
+	Strategy string   `json:"strategy"` // default, coverage
+
+	// ExcludeIngredients are note names or aroma slugs, matched with their
+	// synonyms; perfumes containing any of them are never recommended
+	ExcludeIngredients []string `json:"exclude_ingredients"`
 }
 
 type RecommendationResultResponse struct {
-	Perfume PerfumeResponse `json:"perfume"`
-	Score    float64        `json:"score"`
+	Perfume        PerfumeResponse    `json:"perfume"`
+	Score          float64            `json:"score"`
+	ScoreBreakdown map[string]float64 `json:"score_breakdown"`
+	MatchedTags    []string           `json:"matched_tags"`
 }
 
 type RecommendationResponse struct {
-	Results          []RecommendationResultResponse `json:"results"`
-	BlendExplanation string                     `json:"explanation"`
+	Results               []RecommendationResultResponse `json:"results"`
+	BlendExplanation      string                         `json:"explanation"`
+	Strategy              string                         `json:"strategy"`
+	ExcludedByIngredients int                            `json:"excluded_by_ingredients"`     // candidates dropped for excluded ingredients
+	Experiment            *ExperimentExposureInfo        `json:"experiment,omitempty"`        // set when an A/B experiment served the request
+	RecommendationID      uint                           `json:"recommendation_id,omitempty"` // history record, for impression and click events
 }
@@ -50,7 +50,7 @@ type QuizPreferences struct {
 	SafeBet         bool   `json:"safe_bet"`
 
 	// Budget preference
-	PriceRange      string `json:"price_range"`     // budget, mid, luxury, designer
+	PriceRange      string `json:"price_range"`     // budget, mid, luxury, designer; anything else is ignored
 }
 
 type UserProfile struct {
@@ -97,46 +97,179 @@ type ScentProfile struct {
 	ProfessionalWeight float64 `json:"professional_weight"` // 0-1
 }
 
+// RecommendationRecord is one set of recommendations served to a session
 type RecommendationRecord struct {
-	QuizResponse      QuizPreferences    `json:"quiz_response"`
-	RecommendedPerfumes []PerfumeScore  `json:"recommended_perfumes"`
-	UserFeedback      []FeedbackRecord  `json:"user_feedback"`
-	Timestamp         time.Time         `json:"timestamp"`
+	ID                  uint             `json:"id" gorm:"primaryKey"`
+	SessionID           string           `json:"session_id" gorm:"size:64;index"`         // client-supplied, may be empty
+	Endpoint            string           `json:"endpoint" gorm:"size:20"`                 // quiz, recommend
+	RequestHash         string           `json:"request_hash" gorm:"size:64;index"`       // SHA-256 of the request
+	AlgorithmVersion    string           `json:"algorithm_version" gorm:"size:255"`
+	QuizResponse        *QuizPreferences `json:"quiz_response,omitempty" gorm:"serializer:json;type:text"`
+	RecommendedPerfumes []PerfumeScore   `json:"recommended_perfumes" gorm:"foreignKey:RecordID"`
+	UserFeedback        []FeedbackRecord `json:"user_feedback" gorm:"foreignKey:RecordID"`
+	Timestamp           time.Time        `json:"timestamp" gorm:"index"`
 }
 
+// PerfumeScore is one ranked perfume in a served recommendation set
 type PerfumeScore struct {
-	PerfumeID    uint    `json:"perfume_id"`
-	Perfume      Perfume `json:"perfume"`
-	Score        float64 `json:"score"`
-	MatchReason  string  `json:"match_reason"`
-	Confidence   float64 `json:"confidence"`
+	ID           uint     `json:"-" gorm:"primaryKey"`
+	RecordID     uint     `json:"-" gorm:"not null;index"`
+	Rank         int      `json:"rank" gorm:"column:position"` // "rank" is reserved in some SQL dialects
+	PerfumeID    uint     `json:"perfume_id" gorm:"not null;index"`
+	Perfume      *Perfume `json:"perfume,omitempty" gorm:"-"`
+	Score        float64  `json:"score"`
+	MatchReason  string   `json:"match_reason"`
+	Confidence   float64  `json:"confidence"`
+
+	// Filled in when reading history
+	Seen         bool     `json:"seen" gorm:"-"`    // an impression was recorded
+	Clicked      bool     `json:"clicked" gorm:"-"`
+}
+
+func (PerfumeScore) TableName() string {
+	return "recommendation_items"
 }
 
+// FeedbackRecord is a reaction to one perfume of a served recommendation
 type FeedbackRecord struct {
-	PerfumeID    uint    `json:"perfume_id"`
-	Rating       int     `json:"rating"`         // 1-5
-	Feedback     string  `json:"feedback"`
-	Purchased    bool    `json:"purchased"`
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	RecordID     uint      `json:"recommendation_id" gorm:"not null;index"`
+	SessionID    string    `json:"-" gorm:"size:64;index"` // copied from the record, to apply feedback to later requests
+	PerfumeID    uint      `json:"perfume_id" gorm:"not null"`
+	Type         string    `json:"type" gorm:"not null;size:20"` // thumbs_up, thumbs_down, not_interested, purchased
+	Rating       int       `json:"rating"`         // 1-5, optional
+	Feedback     string    `json:"feedback" gorm:"type:text"`
+	Purchased    bool      `json:"purchased"`
 	Timestamp    time.Time `json:"timestamp"`
 }
 
+func (FeedbackRecord) TableName() string {
+	return "recommendation_feedback"
+}
+
 // Advanced Recommendation Request
 type AdvancedRecommendationRequest struct {
 	QuizPreferences      `json:"quiz_preferences"`
 	CurrentSituation     string `json:"current_situation"`      // work, date, casual, special
-	Season              string `json:"season"`                   // spring, summer, fall, winter
+	Season              string `json:"season"`                   // spring, summer, fall, winter, wet, dry; detected when omitted or unknown
 	TimeOfDay           string `json:"time_of_day"`             // morning, afternoon, evening, night
-	DesiredImpression   string `json:"desired_impression"`      // confident, elegant, playful, mysterious
+	DesiredImpression   string `json:"desired_impression"`      // confident, elegant, playful, mysterious, professional, romantic
 	MaxResults          int    `json:"max_results"`             // default: 6
+	Algorithm           string `json:"algorithm"`               // multi_factor (default), scent_profile
 	ExcludeIDs          []uint `json:"exclude_ids"`             // previously viewed/not interested
+
+	// Note preferences, matched case-insensitively against note names
+	PreferredNotes       []string `json:"preferred_notes"`
+	AvoidedNotes         []string `json:"avoided_notes"`          // lowers the score
+	StronglyAvoidedNotes []string `json:"strongly_avoided_notes"` // perfumes containing these are never recommended
+	// ExcludeIngredients are note names or aroma slugs, matched with their
+	// synonyms, e.g. allergens; perfumes containing any of them are never recommended
+	ExcludeIngredients []string `json:"exclude_ingredients"`
+
+	// Perfumes the user already loves; they seed the community signal and are not recommended back
+	LikedPerfumeIDs []uint `json:"liked_perfume_ids"`
+	// Perfumes the session gave a thumbs up; they seed the community signal but
+	// stay eligible. Filled in from the session's feedback, not by clients.
+	UpvotedPerfumeIDs []uint `json:"-"`
+	// Perfumes the user gave a thumbs down; they stay eligible but rank lower
+	DislikedPerfumeIDs []uint `json:"disliked_perfume_ids"`
+
+	// Diversity trades relevance for variety in brand, category and aroma (0-1).
+	// Omit it to use the configured default; 0 ranks purely by score.
+	Diversity *float64 `json:"diversity"`
+
+	// BudgetMode decides how quiz_preferences.price_range is applied: "soft"
+	// (default) lowers the score of perfumes outside the band, "strict" removes them
+	BudgetMode string `json:"budget_mode"`
+
+	// Used to detect the season when Season is omitted. Latitude sets both the
+	// hemisphere and whether the climate is tropical; Hemisphere and Climate
+	// override it. Missing values fall back to the configured defaults.
+	Date       string   `json:"date"`       // YYYY-MM-DD, defaults to today
+	Hemisphere string   `json:"hemisphere"` // north, south
+	Latitude   *float64 `json:"latitude"`
+	Climate    string   `json:"climate"`    // temperate, tropical
+
+	// Weather refines the season for multi_factor scoring: name a condition or
+	// give the temperature and humidity. Weather "current" asks the weather
+	// provider for the request's location and season instead.
+	Weather     string   `json:"weather"`     // hot_humid, hot_dry, warm, mild, cold, rainy, current
+	Temperature *float64 `json:"temperature"` // °C
+	Humidity    *float64 `json:"humidity"`    // relative, 0-100; assumed 50 when only the temperature is given
+
+	// Gift switches to gift mode: perfumes are scored for the recipient and
+	// quiz_preferences are ignored
+	Gift *GiftRecipient `json:"gift"`
+}
+
+// GiftRecipient describes the person a perfume is bought for
+type GiftRecipient struct {
+	AgeRange           string  `json:"age_range"`            // under_25, 25_40, 40_60, over_60
+	Gender             string  `json:"gender"`               // target audience: men, women, unisex
+	Relationship       string  `json:"relationship"`         // partner, family, friend, colleague
+	FavoritePerfumeIDs []uint  `json:"favorite_perfume_ids"` // perfumes the recipient already loves; not recommended back
+	Occasion           string  `json:"occasion"`             // birthday, anniversary, holiday, thank_you, graduation, wedding
+	Budget             float64 `json:"budget"`               // hard maximum price
 }
 
+const (
+	BudgetModeSoft   = "soft"
+	BudgetModeStrict = "strict"
+)
+
 type AdvancedRecommendationResponse struct {
 	Results              []AdvancedRecommendationResult `json:"results"`
 	PersonalityAnalysis  PersonalityAnalysis           `json:"personality_analysis"`
 	RecommendationLogic  RecommendationLogic          `json:"recommendation_logic"`
 	Tips                 []string                      `json:"tips"`
 	Alternatives         []Perfume                     `json:"alternatives"`
+	ExcludedByNotes      int                           `json:"excluded_by_notes"` // candidates dropped for strongly avoided notes
+	ExcludedByIngredients int                          `json:"excluded_by_ingredients"` // candidates dropped for excluded ingredients
+	Budget               *BudgetSummary                `json:"budget,omitempty"`  // set when a price range was given
+	Season               *SeasonInfo                   `json:"season"`
+	Weather              *WeatherInfo                  `json:"weather,omitempty"` // set when the request gave weather
+	Experiment           *ExperimentExposureInfo       `json:"experiment,omitempty"` // set when an A/B experiment served the request
+	RecommendationID     uint                          `json:"recommendation_id,omitempty"` // history record, for impression, click and feedback events
+	Feedback             *AppliedFeedback              `json:"feedback,omitempty"` // set when the session's earlier feedback shaped the results
+	Warnings             []string                      `json:"warnings,omitempty"` // request options that were ignored, such as an unknown price range or time of day
+}
+
+const (
+	SeasonSourceRequest  = "request"
+	SeasonSourceDetected = "detected"
+)
+
+// SeasonInfo is the season recommendations were scored for and how it was chosen
+type SeasonInfo struct {
+	Season     string `json:"season"`
+	Source     string `json:"source"` // request or detected
+	Date       string `json:"date,omitempty"`
+	Hemisphere string `json:"hemisphere,omitempty"`
+	Climate    string `json:"climate,omitempty"`
+}
+
+const (
+	WeatherSourceRequest  = "request"
+	WeatherSourceProvider = "provider"
+)
+
+// WeatherInfo is the weather recommendations were scored for and how it was chosen
+type WeatherInfo struct {
+	Condition   string   `json:"condition"`             // hot_humid, hot_dry, warm, mild, cold, rainy
+	Source      string   `json:"source"`                // request or provider
+	Temperature *float64 `json:"temperature,omitempty"` // °C, unset for a named condition
+	Humidity    *float64 `json:"humidity,omitempty"`
+	FeelsLike   *float64 `json:"feels_like,omitempty"` // °C, the heat index
+}
+
+// BudgetSummary reports how the results relate to the requested price range
+type BudgetSummary struct {
+	PriceRange     string  `json:"price_range"`
+	Mode           string  `json:"mode"`
+	MinPrice       float64 `json:"min_price"`
+	MaxPrice       float64 `json:"max_price"` // 0 means no upper limit
+	FittingResults int     `json:"fitting_results"`
+	Warning        string  `json:"warning,omitempty"`
 }
 
 type AdvancedRecommendationResult struct {
@@ -149,6 +282,20 @@ type AdvancedRecommendationResult struct {
 	OccasionMatch       float64   `json:"occasion_match"`       // 0-1
 	PerformanceMatch    float64   `json:"performance_match"`    // 0-1
 	UniquenessBonus     float64   `json:"uniqueness_bonus"`     // 0-1
+	NoteMatch           float64   `json:"note_match"`           // 0-1, only set when note preferences were given
+	CommunityMatch      float64   `json:"community_match"`      // 0-1, only set when liked perfumes were given
+	CommunitySource     string    `json:"community_source,omitempty"` // community or content (cold-start fallback)
+	BudgetFit           float64   `json:"budget_fit"`           // 0-1, only set when a price range was given
+	TimeOfDayMatch      float64   `json:"time_of_day_match"`    // 0-1, only set when a time of day was given
+	ImpressionMatch     float64   `json:"impression_match"`     // 0-1, only set when a desired impression was given
+	WeatherMatch        float64   `json:"weather_match"`        // 0-1, only set when weather was given
+
+	// ScoreBreakdown is each component's weighted contribution to OverallScore
+	ScoreBreakdown      map[string]float64 `json:"score_breakdown"`
+	// Explanations are the facts behind each component score
+	Explanations        []Explanation `json:"explanations"`
+
+	MatchedNotes        []MatchedNote `json:"matched_notes"`
 
 	MatchReasons        []string  `json:"match_reasons"`
 	Warnings            []string  `json:"warnings"`
@@ -157,8 +304,21 @@ type AdvancedRecommendationResult struct {
 	Longevity           string    `json:"longevity"`
 	Projection          string    `json:"projection"`
 
+	GiftNote            string    `json:"gift_note,omitempty"`  // why it makes a good gift, set in gift mode
+
 	Confidence          float64   `json:"confidence"`           // 0-1
 	Rank                int       `json:"rank"`
+	RelevanceRank       int       `json:"relevance_rank"`       // rank by overall score alone
+	DiversityAdjusted   bool      `json:"diversity_adjusted"`   // true when re-ranking for diversity moved the result
+}
+
+// MatchedNote is a perfume note that matched one of the requested note preferences
+type MatchedNote struct {
+	NoteName   string   `json:"note_name"`
+	Type       NoteType `json:"type"`
+	Intensity  int      `json:"intensity"`
+	Preference string   `json:"preference"` // preferred or avoided
+	Weight     float64  `json:"weight"`     // 0-1 from intensity and pyramid position
 }
 
 type PersonalityAnalysis struct {
@@ -169,8 +329,10 @@ type PersonalityAnalysis struct {
 }
 
 type RecommendationLogic struct {
-	Algorithm          string   `json:"algorithm"`
+	Algorithm          string   `json:"algorithm"` // the recommender that ranked the results
+	Version            string   `json:"version"`
 	FactorsConsidered  []string `json:"factors_considered"`
 	Weighting          map[string]float64 `json:"weighting"`
+	Diversity          float64  `json:"diversity"`
 	ProcessDescription string   `json:"process_description"`
 }
\ No newline at end of file
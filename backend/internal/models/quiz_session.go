@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+const (
+	QuizSessionInProgress = "in_progress"
+	QuizSessionCompleted  = "completed"
+)
+
+// Why an adaptive quiz session stopped asking questions
+const (
+	QuizStopStable    = "stable"    // the top results stopped changing
+	QuizStopNoGain    = "no_gain"   // no remaining question would split the candidates
+	QuizStopExhausted = "exhausted" // every question was answered
+)
+
+// QuizNoPreference skips a question without changing the preferences
+const QuizNoPreference = "no_preference"
+
+// QuizSession is an adaptive quiz in progress. Answers fill in the
+// QuizPreferences of Request; the rest of Request is fixed when it starts.
+type QuizSession struct {
+	ID              string                        `json:"id" gorm:"primaryKey;size:32"`
+	Request         AdvancedRecommendationRequest `json:"request" gorm:"serializer:json;type:text"`
+	Answers         []QuizAnswer                  `json:"answers" gorm:"serializer:json;type:text"`
+	PendingQuestion string                        `json:"pending_question" gorm:"size:50"` // the question the next answer must be for
+	TopPerfumeIDs   []uint                        `json:"top_perfume_ids" gorm:"serializer:json;type:text"`
+	StableAnswers   int                           `json:"stable_answers"` // consecutive answers that left the top results unchanged
+	Status          string                        `json:"status" gorm:"not null;size:20;index"`
+	StopReason      string                        `json:"stop_reason,omitempty" gorm:"size:20"`
+	Version         int                           `json:"-" gorm:"not null;default:0"` // bumped by every update, so concurrent answers cannot both apply
+	CreatedAt       time.Time                     `json:"created_at"`
+	UpdatedAt       time.Time                     `json:"updated_at"`
+}
+
+// QuizAnswer is one answered question of an adaptive quiz
+type QuizAnswer struct {
+	QuestionID string `json:"question_id" binding:"required"`
+	Answer     string `json:"answer" binding:"required"` // an option value, or no_preference
+}
+
+// QuizQuestionOption is one answer a question offers
+type QuizQuestionOption struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// QuizQuestion is the next question of an adaptive quiz
+type QuizQuestion struct {
+	ID              string               `json:"id"`
+	Text            string               `json:"text"`
+	Options         []QuizQuestionOption `json:"options"`
+	InformationGain float64              `json:"information_gain"` // bits, how well the answers split the candidates
+}
+
+// QuizSessionResponse is the state of an adaptive quiz after starting it or
+// answering a question. Question is set while it is in progress and
+// Recommendations once it is completed.
+type QuizSessionResponse struct {
+	SessionID       string                          `json:"session_id"`
+	Status          string                          `json:"status"`
+	StopReason      string                          `json:"stop_reason,omitempty"`
+	Answers         []QuizAnswer                    `json:"answers"`
+	Question        *QuizQuestion                   `json:"question,omitempty"`
+	TopPerfumeIDs   []uint                          `json:"top_perfume_ids"`
+	StableAnswers   int                             `json:"stable_answers"`
+	Recommendations *AdvancedRecommendationResponse `json:"recommendations,omitempty"`
+}
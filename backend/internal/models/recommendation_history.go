@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Endpoints a recommendation record can come from
+const (
+	RecommendationEndpointQuiz      = "quiz"      // POST /api/quiz/recommendations
+	RecommendationEndpointRecommend = "recommend" // POST /api/recommend
+)
+
+// Interactions with a served recommendation
+const (
+	RecommendationEventImpression = "impression"
+	RecommendationEventClick      = "click"
+)
+
+// RecommendationEvent is an impression or click on one perfume of a recommendation record
+type RecommendationEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RecordID  uint      `json:"record_id" gorm:"not null;index"`
+	PerfumeID uint      `json:"perfume_id" gorm:"not null;index"`
+	Type      string    `json:"type" gorm:"not null;size:20"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RecordRecommendationEventRequest reports an impression or click on a recommended perfume
+type RecordRecommendationEventRequest struct {
+	Type      string `json:"type" binding:"required"` // impression, click
+	PerfumeID uint   `json:"perfume_id" binding:"required"`
+}
+
+// Reactions to a recommended perfume
+const (
+	FeedbackThumbsUp      = "thumbs_up"
+	FeedbackThumbsDown    = "thumbs_down"
+	FeedbackNotInterested = "not_interested"
+	FeedbackPurchased     = "purchased"
+)
+
+// RecommendationFeedbackRequest reacts to one perfume of a served recommendation
+type RecommendationFeedbackRequest struct {
+	PerfumeID uint   `json:"perfume_id" binding:"required"`
+	Type      string `json:"type" binding:"required"` // thumbs_up, thumbs_down, not_interested, purchased
+	Rating    int    `json:"rating"`                  // 1-5, optional
+	Feedback  string `json:"feedback"`                // optional comment
+}
+
+// AppliedFeedback summarizes how a session's earlier feedback changed a request
+type AppliedFeedback struct {
+	Liked     []uint `json:"liked"`     // thumbs up: similar perfumes are boosted, and it can still be recommended
+	Purchased []uint `json:"purchased"` // purchased: similar perfumes are boosted, and it is not recommended again
+	Demoted   []uint `json:"demoted"`   // thumbs down: ranked lower
+	Excluded  []uint `json:"excluded"`  // not interested: never recommended
+}
+
+// UnclickedPerfume is a perfume that keeps being recommended but has never been clicked
+type UnclickedPerfume struct {
+	PerfumeID         uint      `json:"perfume_id"`
+	Name              string    `json:"name"`
+	Brand             string    `json:"brand"`
+	TimesRecommended  int       `json:"times_recommended"`
+	Impressions       int       `json:"impressions"`
+	AverageRank       float64   `json:"average_rank"`
+	LastRecommendedAt time.Time `json:"last_recommended_at"`
+}
@@ -0,0 +1,197 @@
+package models
+
+import "time"
+
+// RecommendationWeights are the relative weights of the quiz scoring components.
+// They are normalized by their sum when scoring, so they do not have to add up to 1.
+type RecommendationWeights struct {
+	Profile     float64 `json:"profile"`
+	Season      float64 `json:"season"`
+	Occasion    float64 `json:"occasion"`
+	Performance float64 `json:"performance"`
+	Uniqueness  float64 `json:"uniqueness"`
+	Notes       float64 `json:"notes"`       // only applied when the request names preferred or avoided notes
+	Community   float64 `json:"community"`   // only applied when the request names liked perfumes
+	Budget      float64 `json:"budget"`      // only applied when the quiz answers include a price range
+	TimeOfDay   float64 `json:"time_of_day"` // only applied when the request names a time of day
+	Impression  float64 `json:"impression"`  // only applied when the request names a desired impression
+	Weather     float64 `json:"weather"`     // only applied when the request gives weather
+}
+
+// RecommendationThresholds are the cut-offs used by the quiz scoring components
+type RecommendationThresholds struct {
+	MatchReason     float64 `json:"match_reason"`      // component score above which a match reason is shown (0-1)
+	ValuePriceMax   float64 `json:"value_price_max"`   // price below which a perfume is called great value
+	CasualPriceMax  float64 `json:"casual_price_max"`  // price below which a light perfume suits casual wear
+	SpecialPriceMin float64 `json:"special_price_min"` // price above which a perfume suits special occasions
+	PremiumPriceMin float64 `json:"premium_price_min"` // price above which a perfume earns the full uniqueness bonus
+	SafeBetPriceMax float64 `json:"safe_bet_price_max"`
+}
+
+// DiversitySettings control the maximal-marginal-relevance re-ranking of quiz results
+type DiversitySettings struct {
+	Default  float64 `json:"default"`  // trade-off used when a request does not set one: 0 ranks by score only, 1 by novelty only
+	Brand    float64 `json:"brand"`    // weight of sharing a brand when comparing two perfumes
+	Category float64 `json:"category"` // weight of sharing a category
+	Aroma    float64 `json:"aroma"`    // weight of overlapping aroma tags
+}
+
+// PriceBand is an inclusive price range; a Max of 0 means no upper limit
+type PriceBand struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// Contains reports whether the price falls inside the band
+func (b PriceBand) Contains(price float64) bool {
+	return price >= b.Min && (b.Max == 0 || price <= b.Max)
+}
+
+// BudgetSettings map the quiz price ranges to price bands
+type BudgetSettings struct {
+	Budget   PriceBand `json:"budget"`
+	Mid      PriceBand `json:"mid"`
+	Luxury   PriceBand `json:"luxury"`
+	Designer PriceBand `json:"designer"`
+
+	// Tolerance is how far outside a band, as a share of the band edge, a price
+	// can be before its budget fit drops to 0 in soft mode
+	Tolerance float64 `json:"tolerance"`
+	// MinFittingResults is how many results should fit the budget before the response warns
+	MinFittingResults int `json:"min_fitting_results"`
+}
+
+// Band returns the price band of a quiz price range
+func (b BudgetSettings) Band(priceRange string) (PriceBand, bool) {
+	switch priceRange {
+	case "budget":
+		return b.Budget, true
+	case "mid":
+		return b.Mid, true
+	case "luxury":
+		return b.Luxury, true
+	case "designer":
+		return b.Designer, true
+	}
+	return PriceBand{}, false
+}
+
+// SeasonSettings are the location assumed when a request names neither a season nor a location
+type SeasonSettings struct {
+	Hemisphere string `json:"hemisphere"` // north or south
+	Climate    string `json:"climate"`    // temperate (four seasons) or tropical (wet and dry)
+}
+
+// RecommendationSettings is the tunable configuration of the quiz recommender
+type RecommendationSettings struct {
+	Weights    RecommendationWeights    `json:"weights"`
+	Thresholds RecommendationThresholds `json:"thresholds"`
+	Diversity  DiversitySettings        `json:"diversity"`
+	Budget     BudgetSettings           `json:"budget"`
+	Season     SeasonSettings           `json:"season"`
+}
+
+// DefaultRecommendationSettings returns the weights and thresholds the quiz recommender shipped with
+func DefaultRecommendationSettings() RecommendationSettings {
+	return RecommendationSettings{
+		Weights: RecommendationWeights{
+			Profile:     0.4,
+			Season:      0.2,
+			Occasion:    0.2,
+			Performance: 0.1,
+			Uniqueness:  0.1,
+			Notes:       0.2,
+			Community:   0.15,
+			Budget:      0.15,
+			TimeOfDay:   0.1,
+			Impression:  0.1,
+			Weather:     0.15,
+		},
+		Thresholds: RecommendationThresholds{
+			MatchReason:     0.7,
+			ValuePriceMax:   100,
+			CasualPriceMax:  150,
+			SpecialPriceMin: 100,
+			PremiumPriceMin: 200,
+			SafeBetPriceMax: 100,
+		},
+		Diversity: DiversitySettings{
+			Default:  0.2,
+			Brand:    0.4,
+			Category: 0.3,
+			Aroma:    0.3,
+		},
+		Budget: BudgetSettings{
+			Budget:            PriceBand{Min: 0, Max: 60},
+			Mid:               PriceBand{Min: 60, Max: 120},
+			Luxury:            PriceBand{Min: 120, Max: 200},
+			Designer:          PriceBand{Min: 200},
+			Tolerance:         0.5,
+			MinFittingResults: 3,
+		},
+		// Most of our users are in Indonesia
+		Season: SeasonSettings{
+			Hemisphere: "south",
+			Climate:    "tropical",
+		},
+	}
+}
+
+// RecommendationSettingsRecord is one saved revision of the recommender settings.
+// The newest row is the active configuration; older rows are kept as history.
+type RecommendationSettingsRecord struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Payload   string    `json:"-" gorm:"not null;type:text"` // RecommendationSettings as JSON
+	UpdatedBy string    `json:"updated_by" gorm:"size:255"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RecommendationSettingsResponse is the admin view of the active settings
+type RecommendationSettingsResponse struct {
+	RecommendationSettings
+	Version   uint      `json:"version"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WeightComponents names the weighted score components in a fixed order, for
+// summing them the same way every time (map iteration order is random)
+var WeightComponents = []string{"profile", "season", "occasion", "performance", "uniqueness", "notes", "community", "budget", "time_of_day", "impression", "weather"}
+
+// AsMap returns the weights keyed by component name
+func (w RecommendationWeights) AsMap() map[string]float64 {
+	return map[string]float64{
+		"profile":     w.Profile,
+		"season":      w.Season,
+		"occasion":    w.Occasion,
+		"performance": w.Performance,
+		"uniqueness":  w.Uniqueness,
+		"notes":       w.Notes,
+		"community":   w.Community,
+		"budget":      w.Budget,
+		"time_of_day": w.TimeOfDay,
+		"impression":  w.Impression,
+		"weather":     w.Weather,
+	}
+}
+
+// Normalized returns the weights scaled so that they add up to 1
+func (w RecommendationWeights) Normalized() RecommendationWeights {
+	total := w.Profile + w.Season + w.Occasion + w.Performance + w.Uniqueness + w.Notes + w.Community + w.Budget + w.TimeOfDay + w.Impression + w.Weather
+	if total <= 0 {
+		return w
+	}
+	return RecommendationWeights{
+		Profile:     w.Profile / total,
+		Season:      w.Season / total,
+		Occasion:    w.Occasion / total,
+		Performance: w.Performance / total,
+		Uniqueness:  w.Uniqueness / total,
+		Notes:       w.Notes / total,
+		Community:   w.Community / total,
+		Budget:      w.Budget / total,
+		TimeOfDay:   w.TimeOfDay / total,
+		Impression:  w.Impression / total,
+		Weather:     w.Weather / total,
+	}
+}
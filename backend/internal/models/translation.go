@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// TranslationEntity identifies which catalog entity a translation belongs to
+type TranslationEntity string
+
+const (
+	// TranslationEntityAromaTag translations are keyed by aroma tag slug
+	TranslationEntityAromaTag TranslationEntity = "aroma_tag"
+	// TranslationEntityNote translations are keyed by lower-cased note name,
+	// so one translation covers every perfume using that note
+	TranslationEntityNote TranslationEntity = "note"
+	// TranslationEntityPerfume translations are keyed by perfume ID
+	TranslationEntityPerfume TranslationEntity = "perfume"
+	// TranslationEntityPersonality translations are keyed by the lower-cased
+	// name of a quiz personality type, such as "the creative soul"
+	TranslationEntityPersonality TranslationEntity = "personality"
+)
+
+const (
+	TranslationFieldName        = "name"
+	TranslationFieldDescription = "description"
+)
+
+// Translation stores a localized value for a single field of a catalog entity
+type Translation struct {
+	ID         uint              `json:"id" gorm:"primaryKey"`
+	EntityType TranslationEntity `json:"entity_type" gorm:"not null;size:50;uniqueIndex:idx_translation_lookup"`
+	EntityKey  string            `json:"entity_key" gorm:"not null;size:255;uniqueIndex:idx_translation_lookup"`
+	Field      string            `json:"field" gorm:"not null;size:50;uniqueIndex:idx_translation_lookup"`
+	Locale     string            `json:"locale" gorm:"not null;size:20;uniqueIndex:idx_translation_lookup;index"`
+	Value      string            `json:"value" gorm:"not null;type:text"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// UpsertTranslationRequest creates or replaces a translation
+type UpsertTranslationRequest struct {
+	EntityType TranslationEntity `json:"entity_type" binding:"required"`
+	EntityKey  string            `json:"entity_key" binding:"required"`
+	Field      string            `json:"field" binding:"required"`
+	Locale     string            `json:"locale" binding:"required"`
+	Value      string            `json:"value" binding:"required"`
+}
+
+// MissingTranslation is a source value that has no translation in a locale
+type MissingTranslation struct {
+	EntityType TranslationEntity `json:"entity_type"`
+	EntityKey  string            `json:"entity_key"`
+	Field      string            `json:"field"`
+	SourceText string            `json:"source_text"`
+}
+
+// TranslationCoverage summarizes how much of one entity type is translated
+type TranslationCoverage struct {
+	EntityType TranslationEntity `json:"entity_type"`
+	Total      int               `json:"total"`
+	Translated int               `json:"translated"`
+	Missing    int               `json:"missing"`
+}
+
+// MissingTranslationsReport lists untranslated catalog text for a locale
+type MissingTranslationsReport struct {
+	Locale   string                `json:"locale"`
+	Coverage []TranslationCoverage `json:"coverage"`
+	Missing  []MissingTranslation  `json:"missing"`
+}
@@ -0,0 +1,54 @@
+package models
+
+// Wardrobe dimensions a collection is profiled across
+const (
+	WardrobeDimensionSeason   = "season"
+	WardrobeDimensionOccasion = "occasion"
+	WardrobeDimensionFamily   = "family"
+	WardrobeDimensionSillage  = "sillage"
+)
+
+// WardrobeGapsRequest lists the perfumes a collector owns
+type WardrobeGapsRequest struct {
+	OwnedPerfumeIDs []uint `json:"owned_perfume_ids"`
+	MaxGaps         int    `json:"max_gaps"` // default: 3, max: 10
+	Limit           int    `json:"limit"`    // picks per gap, default: 3, max: 12
+
+	// Pick the seasons profiled: wet and dry in the tropics, the four
+	// temperate seasons elsewhere. Climate overrides Latitude; both default
+	// to the configured season settings.
+	Latitude *float64 `json:"latitude"`
+	Climate  string   `json:"climate"` // temperate, tropical
+}
+
+// WardrobeCoverage is how well the collection covers one season, occasion,
+// aroma family or sillage level
+type WardrobeCoverage struct {
+	Dimension string  `json:"dimension"`
+	Value     string  `json:"value"`
+	Coverage  float64 `json:"coverage"`   // 0-1, the best fit of any owned perfume
+	CoveredBy []uint  `json:"covered_by"` // owned perfumes that fit it
+}
+
+// WardrobeGapPick is a perfume that fills a gap
+type WardrobeGapPick struct {
+	Perfume      Perfume       `json:"perfume"`
+	Score        float64       `json:"score"`      // 0-1, fit discounted by closeness to the collection
+	Fit          float64       `json:"fit"`        // 0-1, how well it covers the gap
+	Similarity   float64       `json:"similarity"` // 0-1, to the closest owned perfume
+	ClosestOwned uint          `json:"closest_owned_id,omitempty"`
+	Explanations []Explanation `json:"explanations"`
+}
+
+// WardrobeGap is one of the weakest spots of the collection with the perfumes that fill it
+type WardrobeGap struct {
+	WardrobeCoverage
+	Picks []WardrobeGapPick `json:"picks"`
+}
+
+type WardrobeGapsResponse struct {
+	OwnedPerfumeIDs []uint             `json:"owned_perfume_ids"`
+	Climate         string             `json:"climate"` // decides the seasons profiled
+	Coverage        []WardrobeCoverage `json:"coverage"`
+	Gaps            []WardrobeGap      `json:"gaps"`
+}
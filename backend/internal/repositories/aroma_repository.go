@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"fmt"
+
 	"perfume-website/internal/models"
 
 	"gorm.io/gorm"
@@ -15,6 +17,8 @@ type AromaRepository interface {
 	Delete(id uint) error
 	GetBySlugs(slugs []string) ([]models.AromaTag, error)
 	Count() (int64, error)
+	GetUsageStats() ([]models.AromaTagStats, error)
+	GetCooccurrences() ([]models.AromaCooccurrence, error)
 }
 
 type aromaRepository struct {
@@ -72,3 +76,69 @@ func (r *aromaRepository) Count() (int64, error) {
 	err := r.db.Model(&models.AromaTag{}).Count(&count).Error
 	return count, err
 }
+
+// GetUsageStats returns perfume count, average price and average review rating per aroma tag
+func (r *aromaRepository) GetUsageStats() ([]models.AromaTagStats, error) {
+	var stats []models.AromaTagStats
+	err := r.db.Table("aroma_tags").
+		Select("aroma_tags.id AS aroma_tag_id, aroma_tags.slug, aroma_tags.name, " +
+			"COUNT(perfumes.id) AS perfume_count, COALESCE(AVG(perfumes.price), 0) AS average_price").
+		Joins("LEFT JOIN perfume_aromas ON perfume_aromas.aroma_tag_id = aroma_tags.id").
+		Joins("LEFT JOIN perfumes ON perfumes.id = perfume_aromas.perfume_id AND perfumes.deleted_at IS NULL").
+		Where("aroma_tags.deleted_at IS NULL").
+		Group("aroma_tags.id").
+		Order("perfume_count DESC, aroma_tags.slug ASC").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aroma usage stats: %w", err)
+	}
+
+	// Ratings are aggregated separately so that perfumes with many reviews
+	// do not inflate the perfume count and average price above
+	var ratings []struct {
+		AromaTagID    uint
+		AverageRating float64
+		ReviewCount   int64
+	}
+	err = r.db.Table("perfume_aromas").
+		Select("perfume_aromas.aroma_tag_id, AVG(enhanced_reviews.overall_rating) AS average_rating, " +
+			"COUNT(enhanced_reviews.id) AS review_count").
+		Joins("JOIN perfumes ON perfumes.id = perfume_aromas.perfume_id AND perfumes.deleted_at IS NULL").
+		Joins("JOIN enhanced_reviews ON enhanced_reviews.perfume_id = perfume_aromas.perfume_id").
+		Group("perfume_aromas.aroma_tag_id").
+		Scan(&ratings).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aroma rating stats: %w", err)
+	}
+
+	ratingsByTag := make(map[uint]int, len(ratings))
+	for i, rating := range ratings {
+		ratingsByTag[rating.AromaTagID] = i
+	}
+	for i := range stats {
+		if idx, ok := ratingsByTag[stats[i].AromaTagID]; ok {
+			stats[i].AverageRating = ratings[idx].AverageRating
+			stats[i].ReviewCount = ratings[idx].ReviewCount
+		}
+	}
+
+	return stats, nil
+}
+
+// GetCooccurrences returns the number of perfumes carrying each pair of aroma tags
+func (r *aromaRepository) GetCooccurrences() ([]models.AromaCooccurrence, error) {
+	var pairs []models.AromaCooccurrence
+	err := r.db.Table("perfume_aromas AS a").
+		Select("a.aroma_tag_id AS aroma_tag_id, ta.slug AS slug, " +
+			"b.aroma_tag_id AS other_aroma_tag_id, tb.slug AS other_slug, COUNT(*) AS count").
+		Joins("JOIN perfume_aromas AS b ON b.perfume_id = a.perfume_id AND b.aroma_tag_id > a.aroma_tag_id").
+		Joins("JOIN perfumes ON perfumes.id = a.perfume_id AND perfumes.deleted_at IS NULL").
+		Joins("JOIN aroma_tags AS ta ON ta.id = a.aroma_tag_id AND ta.deleted_at IS NULL").
+		Joins("JOIN aroma_tags AS tb ON tb.id = b.aroma_tag_id AND tb.deleted_at IS NULL").
+		Group("a.aroma_tag_id, b.aroma_tag_id").
+		Scan(&pairs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aroma co-occurrences: %w", err)
+	}
+	return pairs, nil
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"perfume-website/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExperimentRepository interface {
+	Create(experiment *models.Experiment) error
+	GetByID(id uint) (*models.Experiment, error)
+	GetAll() ([]models.Experiment, error)
+	GetRunning() ([]models.Experiment, error)
+	Update(experiment *models.Experiment) error
+
+	GetAssignment(experimentID uint, anonymousID string) (*models.ExperimentAssignment, error)
+	CreateAssignment(assignment *models.ExperimentAssignment) error
+	CreateExposure(exposure *models.ExperimentExposure) error
+	CreateEvent(event *models.ExperimentEvent) error
+	GetVariantStats(experimentID uint) ([]models.VariantStats, error)
+}
+
+type experimentRepository struct {
+	db *gorm.DB
+}
+
+func NewExperimentRepository(db *gorm.DB) ExperimentRepository {
+	return &experimentRepository{db: db}
+}
+
+func (r *experimentRepository) Create(experiment *models.Experiment) error {
+	return r.db.Create(experiment).Error
+}
+
+func (r *experimentRepository) GetByID(id uint) (*models.Experiment, error) {
+	var experiment models.Experiment
+	if err := r.db.First(&experiment, id).Error; err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// GetAll returns every experiment, newest first
+func (r *experimentRepository) GetAll() ([]models.Experiment, error) {
+	var experiments []models.Experiment
+	err := r.db.Order("id DESC").Find(&experiments).Error
+	return experiments, err
+}
+
+func (r *experimentRepository) GetRunning() ([]models.Experiment, error) {
+	var experiments []models.Experiment
+	err := r.db.Where("status = ?", models.ExperimentStatusRunning).Find(&experiments).Error
+	return experiments, err
+}
+
+func (r *experimentRepository) Update(experiment *models.Experiment) error {
+	return r.db.Save(experiment).Error
+}
+
+func (r *experimentRepository) GetAssignment(experimentID uint, anonymousID string) (*models.ExperimentAssignment, error) {
+	var assignment models.ExperimentAssignment
+	err := r.db.Where("experiment_id = ? AND anonymous_id = ?", experimentID, anonymousID).First(&assignment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// CreateAssignment stores an assignment unless the visitor already has one,
+// which can happen when two of their requests race
+func (r *experimentRepository) CreateAssignment(assignment *models.ExperimentAssignment) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(assignment).Error
+}
+
+func (r *experimentRepository) CreateExposure(exposure *models.ExperimentExposure) error {
+	return r.db.Create(exposure).Error
+}
+
+func (r *experimentRepository) CreateEvent(event *models.ExperimentEvent) error {
+	return r.db.Create(event).Error
+}
+
+// GetVariantStats counts exposed visitors per variant, and for each event type
+// the visitors whose event followed one of their exposures
+func (r *experimentRepository) GetVariantStats(experimentID uint) ([]models.VariantStats, error) {
+	var exposures []struct {
+		Variant   string
+		Users     int
+		Exposures int
+	}
+	err := r.db.Model(&models.ExperimentExposure{}).
+		Select("variant, COUNT(DISTINCT anonymous_id) AS users, COUNT(*) AS exposures").
+		Where("experiment_id = ?", experimentID).
+		Group("variant").
+		Scan(&exposures).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var conversions []struct {
+		Variant   string
+		Type      string
+		Converted int
+	}
+	err = r.db.Model(&models.ExperimentEvent{}).
+		Select("variant, type, COUNT(DISTINCT anonymous_id) AS converted").
+		Where("experiment_id = ?", experimentID).
+		Where(`EXISTS (SELECT 1 FROM experiment_exposures x
+			WHERE x.experiment_id = experiment_events.experiment_id
+			AND x.anonymous_id = experiment_events.anonymous_id
+			AND x.variant = experiment_events.variant
+			AND x.created_at <= experiment_events.created_at)`).
+		Group("variant, type").
+		Scan(&conversions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.VariantStats, 0, len(exposures))
+	index := make(map[string]int, len(exposures))
+	for _, row := range exposures {
+		index[row.Variant] = len(stats)
+		stats = append(stats, models.VariantStats{
+			Variant:   row.Variant,
+			Users:     row.Users,
+			Exposures: row.Exposures,
+			Converted: make(map[string]int),
+		})
+	}
+	for _, row := range conversions {
+		if i, ok := index[row.Variant]; ok {
+			stats[i].Converted[row.Type] = row.Converted
+		}
+	}
+	return stats, nil
+}
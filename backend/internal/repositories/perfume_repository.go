@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"fmt"
+	"time"
 
 	"perfume-website/internal/models"
 
@@ -17,9 +18,10 @@ type PerfumeRepository interface {
 	GetByAromaTags(aromaTagIDs []uint) ([]models.Perfume, error)
 	GetWithRelations(id uint) (*models.Perfume, error)
 	GetAllWithRelations() ([]models.Perfume, error)
-	GetWithPagination(page, limit int, search, brand, aroma string) ([]models.Perfume, int64, error)
+	GetWithPagination(page, limit int, search, brand, aroma string, excludeIDs []uint) ([]models.Perfume, int64, int64, error)
 	GetAllPerfumes() ([]models.Perfume, error)
 	Count() (int64, error)
+	ReplaceAccords(perfumeID uint, accords []models.PerfumeAroma) error
 //GetCategories() ([]map[string]interface{}, error)
 }
 
@@ -52,7 +54,7 @@ func (r *perfumeRepository) GetAll() ([]models.Perfume, error) {
 
 func (r *perfumeRepository) GetWithRelations(id uint) (*models.Perfume, error) {
 	var perfume models.Perfume
-	err := r.db.Preload("AromaTags").Preload("Notes").First(&perfume, id).Error
+	err := r.db.Preload("AromaTags").Preload("Notes").Preload("Accords").First(&perfume, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +63,7 @@ func (r *perfumeRepository) GetWithRelations(id uint) (*models.Perfume, error) {
 
 func (r *perfumeRepository) GetAllWithRelations() ([]models.Perfume, error) {
 	var perfumes []models.Perfume
-	err := r.db.Preload("AromaTags").Preload("Notes").Find(&perfumes).Error
+	err := r.db.Preload("AromaTags").Preload("Notes").Preload("Accords").Find(&perfumes).Error
 	return perfumes, err
 }
 
@@ -77,7 +79,7 @@ func (r *perfumeRepository) GetByAromaTags(aromaTagIDs []uint) ([]models.Perfume
 	var perfumes []models.Perfume
 	
 	// Query perfumes that have any of the specified aroma tags
-	err := r.db.Preload("AromaTags").Preload("Notes").
+	err := r.db.Preload("AromaTags").Preload("Notes").Preload("Accords").
 		Joins("JOIN perfume_aromas ON perfume_aromas.perfume_id = perfumes.id").
 		Where("perfume_aromas.aroma_tag_id IN ?", aromaTagIDs).
 		Group("perfumes.id").
@@ -96,8 +98,9 @@ func (r *perfumeRepository) Count() (int64, error) {
 	return count, err
 }
 
-// GetWithPagination retrieves perfumes with pagination and filtering
-func (r *perfumeRepository) GetWithPagination(page, limit int, search, brand, aroma string) ([]models.Perfume, int64, error) {
+// GetWithPagination retrieves perfumes with pagination and filtering. It also
+// returns how many perfumes matching the filters were left out by excludeIDs.
+func (r *perfumeRepository) GetWithPagination(page, limit int, search, brand, aroma string, excludeIDs []uint) ([]models.Perfume, int64, int64, error) {
 	var perfumes []models.Perfume
 	var total int64
 
@@ -119,30 +122,71 @@ func (r *perfumeRepository) GetWithPagination(page, limit int, search, brand, ar
 			Where("aroma_tags.slug = ?", aroma)
 	}
 
+	var excluded int64
+	if len(excludeIDs) > 0 {
+		if err := query.Count(&excluded).Error; err != nil {
+			return nil, 0, 0, err
+		}
+		query = query.Where("perfumes.id NOT IN ?", excludeIDs)
+	}
+
 	// Get total count
 	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, 0, err
+	}
+	if len(excludeIDs) > 0 {
+		excluded -= total
 	}
 
 	// Get paginated results with relations
-	err := query.Preload("AromaTags").Preload("Notes").
+	err := query.Preload("AromaTags").Preload("Notes").Preload("Accords").
 		Offset(offset).
 		Limit(limit).
 		Find(&perfumes).Error
 
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, 0, err
 	}
 
-	return perfumes, total, nil
+	return perfumes, total, excluded, nil
 }
 
 // GetAllPerfumes returns all perfumes with relations
 func (r *perfumeRepository) GetAllPerfumes() ([]models.Perfume, error) {
 	var perfumes []models.Perfume
-	err := r.db.Preload("AromaTags").Preload("Notes").Find(&perfumes).Error
+	err := r.db.Preload("AromaTags").Preload("Notes").Preload("Accords").Find(&perfumes).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all perfumes: %w", err)
 	}
 	return perfumes, nil
 }
+
+// ReplaceAccords swaps the perfume's aroma tag associations for the given weighted set
+func (r *perfumeRepository) ReplaceAccords(perfumeID uint, accords []models.PerfumeAroma) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("perfume_id = ?", perfumeID).Delete(&models.PerfumeAroma{}).Error; err != nil {
+			return fmt.Errorf("failed to clear accords: %w", err)
+		}
+		if len(accords) == 0 {
+			return nil
+		}
+
+		// Insert through maps so that an explicit strength of 0 is not replaced by the column default
+		now := time.Now()
+		rows := make([]map[string]interface{}, 0, len(accords))
+		for i := range accords {
+			accords[i].PerfumeID = perfumeID
+			accords[i].CreatedAt = now
+			rows = append(rows, map[string]interface{}{
+				"perfume_id":   perfumeID,
+				"aroma_tag_id": accords[i].AromaTagID,
+				"strength":     accords[i].Strength,
+				"created_at":   now,
+			})
+		}
+		if err := tx.Model(&models.PerfumeAroma{}).Create(rows).Error; err != nil {
+			return fmt.Errorf("failed to save accords: %w", err)
+		}
+		return nil
+	})
+}
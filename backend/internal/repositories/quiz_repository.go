@@ -31,6 +31,13 @@ func (r *QuizRepository) GetQuizByID(id uint) (*models.PersonalityQuiz, error) {
 	return &quiz, nil
 }
 
+// GetAllQuizzes returns every saved quiz response, oldest first
+func (r *QuizRepository) GetAllQuizzes() ([]models.PersonalityQuiz, error) {
+	var quizzes []models.PersonalityQuiz
+	err := r.db.Order("id").Find(&quizzes).Error
+	return quizzes, err
+}
+
 // GetQuizStatistics returns statistics about quiz responses
 func (r *QuizRepository) GetQuizStatistics() (map[string]interface{}, error) {
 	var stats map[string]interface{}
@@ -122,6 +129,7 @@ func (r *QuizRepository) GetPopularPerfumesByQuizData(preferences models.QuizPre
 	// querying based on the quiz data and user interactions
 	query := r.db.Preload("AromaTags").
 		Preload("Notes").
+		Preload("Accords").
 		Joins("INNER JOIN perfume_aromas ON perfume_aromas.perfume_id = perfumes.id").
 		Joins("INNER JOIN aroma_tags ON aroma_tags.id = perfume_aromas.aroma_tag_id").
 		Group("perfumes.id").
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"errors"
+
+	"perfume-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrStaleQuizSession is returned when a session was updated since it was read
+var ErrStaleQuizSession = errors.New("quiz session was updated concurrently")
+
+type QuizSessionRepository interface {
+	Create(session *models.QuizSession) error
+	GetByID(id string) (*models.QuizSession, error)
+	Update(session *models.QuizSession) error
+}
+
+type quizSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewQuizSessionRepository(db *gorm.DB) QuizSessionRepository {
+	return &quizSessionRepository{db: db}
+}
+
+func (r *quizSessionRepository) Create(session *models.QuizSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *quizSessionRepository) GetByID(id string) (*models.QuizSession, error) {
+	var session models.QuizSession
+	if err := r.db.Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Update saves the session only if it still has the version it was read with,
+// and bumps the version
+func (r *quizSessionRepository) Update(session *models.QuizSession) error {
+	version := session.Version
+	session.Version++
+	result := r.db.Model(session).Where("version = ?", version).Select("*").Updates(session)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrStaleQuizSession
+	}
+	if result.Error != nil {
+		session.Version = version
+	}
+	return result.Error
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"time"
+
+	"perfume-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type RecommendationHistoryRepository interface {
+	Create(record *models.RecommendationRecord) error
+	GetByID(id uint) (*models.RecommendationRecord, error)
+	GetBySession(sessionID string, limit int) ([]models.RecommendationRecord, error)
+	CreateEvent(event *models.RecommendationEvent) error
+	GetEvents(recordIDs []uint) ([]models.RecommendationEvent, error)
+	GetUnclickedPerfumes(minRecommended, limit int) ([]models.UnclickedPerfume, error)
+	CreateFeedback(feedback *models.FeedbackRecord) error
+	GetFeedbackBySession(sessionID string) ([]models.FeedbackRecord, error)
+}
+
+type recommendationHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewRecommendationHistoryRepository(db *gorm.DB) RecommendationHistoryRepository {
+	return &recommendationHistoryRepository{db: db}
+}
+
+// Create stores the record together with its ranked perfumes
+func (r *recommendationHistoryRepository) Create(record *models.RecommendationRecord) error {
+	return r.db.Create(record).Error
+}
+
+func (r *recommendationHistoryRepository) GetByID(id uint) (*models.RecommendationRecord, error) {
+	var record models.RecommendationRecord
+	err := r.db.Preload("RecommendedPerfumes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Preload("UserFeedback").First(&record, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// GetBySession returns a session's records, newest first
+func (r *recommendationHistoryRepository) GetBySession(sessionID string, limit int) ([]models.RecommendationRecord, error) {
+	var records []models.RecommendationRecord
+	err := r.db.Preload("RecommendedPerfumes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).
+		Preload("UserFeedback").
+		Where("session_id = ?", sessionID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&records).Error
+	return records, err
+}
+
+func (r *recommendationHistoryRepository) CreateEvent(event *models.RecommendationEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *recommendationHistoryRepository) GetEvents(recordIDs []uint) ([]models.RecommendationEvent, error) {
+	var events []models.RecommendationEvent
+	if len(recordIDs) == 0 {
+		return events, nil
+	}
+	err := r.db.Where("record_id IN ?", recordIDs).Order("id").Find(&events).Error
+	return events, err
+}
+
+// GetUnclickedPerfumes returns perfumes recommended at least minRecommended
+// times that no one has clicked in any recommendation, most recommended first
+func (r *recommendationHistoryRepository) GetUnclickedPerfumes(minRecommended, limit int) ([]models.UnclickedPerfume, error) {
+	var rows []struct {
+		models.UnclickedPerfume
+		LastRecordID uint
+	}
+	err := r.db.Table("recommendation_items AS i").
+		Select(`i.perfume_id, p.name, p.brand,
+			COUNT(*) AS times_recommended,
+			AVG(i.position) AS average_rank,
+			MAX(i.record_id) AS last_record_id,
+			(SELECT COUNT(*) FROM recommendation_events e
+				WHERE e.perfume_id = i.perfume_id AND e.type = ?) AS impressions`, models.RecommendationEventImpression).
+		Joins("LEFT JOIN perfumes AS p ON p.id = i.perfume_id").
+		Where(`NOT EXISTS (SELECT 1 FROM recommendation_events e
+			WHERE e.perfume_id = i.perfume_id AND e.type = ?)`, models.RecommendationEventClick).
+		Group("i.perfume_id, p.name, p.brand").
+		Having("COUNT(*) >= ?", minRecommended).
+		Order("times_recommended DESC, i.perfume_id").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Aggregated datetimes come back as driver-specific strings, so read the
+	// newest record's timestamp separately
+	recordIDs := make([]uint, len(rows))
+	for i, row := range rows {
+		recordIDs[i] = row.LastRecordID
+	}
+	var records []models.RecommendationRecord
+	if len(recordIDs) > 0 {
+		if err := r.db.Select("id, timestamp").Where("id IN ?", recordIDs).Find(&records).Error; err != nil {
+			return nil, err
+		}
+	}
+	timestamps := make(map[uint]time.Time, len(records))
+	for _, record := range records {
+		timestamps[record.ID] = record.Timestamp
+	}
+
+	perfumes := make([]models.UnclickedPerfume, len(rows))
+	for i, row := range rows {
+		perfumes[i] = row.UnclickedPerfume
+		perfumes[i].LastRecommendedAt = timestamps[row.LastRecordID]
+	}
+	return perfumes, nil
+}
+
+func (r *recommendationHistoryRepository) CreateFeedback(feedback *models.FeedbackRecord) error {
+	return r.db.Create(feedback).Error
+}
+
+// GetFeedbackBySession returns a session's feedback, oldest first
+func (r *recommendationHistoryRepository) GetFeedbackBySession(sessionID string) ([]models.FeedbackRecord, error) {
+	var feedback []models.FeedbackRecord
+	err := r.db.Where("session_id = ?", sessionID).Order("id").Find(&feedback).Error
+	return feedback, err
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"perfume-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type RecommendationSettingsRepository interface {
+	GetLatest() (*models.RecommendationSettingsRecord, error)
+	Create(record *models.RecommendationSettingsRecord) error
+}
+
+type recommendationSettingsRepository struct {
+	db *gorm.DB
+}
+
+func NewRecommendationSettingsRepository(db *gorm.DB) RecommendationSettingsRepository {
+	return &recommendationSettingsRepository{db: db}
+}
+
+// GetLatest returns the newest settings revision
+func (r *recommendationSettingsRepository) GetLatest() (*models.RecommendationSettingsRecord, error) {
+	var record models.RecommendationSettingsRecord
+	err := r.db.Order("id DESC").First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Create stores a new settings revision
+func (r *recommendationSettingsRepository) Create(record *models.RecommendationSettingsRecord) error {
+	return r.db.Create(record).Error
+}
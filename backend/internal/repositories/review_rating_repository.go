@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"perfume-website/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ReviewRatingRepository interface {
+	GetAllRatings() ([]models.ItemRating, error)
+}
+
+type reviewRatingRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRatingRepository(db *gorm.DB) ReviewRatingRepository {
+	return &reviewRatingRepository{db: db}
+}
+
+// GetAllRatings returns every overall rating in enhanced reviews, oldest first,
+// identifying reviewers by email and falling back to their name
+func (r *reviewRatingRepository) GetAllRatings() ([]models.ItemRating, error) {
+	var ratings []models.ItemRating
+	err := r.db.Model(&models.EnhancedReviewGORM{}).
+		Select("perfume_id, LOWER(COALESCE(NULLIF(TRIM(user_email), ''), TRIM(user_name))) AS reviewer, overall_rating AS rating").
+		Where("overall_rating BETWEEN 1 AND 5").
+		Order("id").
+		Scan(&ratings).Error
+	return ratings, err
+}
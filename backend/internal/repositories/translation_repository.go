@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"fmt"
+	"strconv"
+
+	"perfume-website/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TranslationRepository interface {
+	Upsert(translation *models.Translation) error
+	GetByID(id uint) (*models.Translation, error)
+	Delete(id uint) error
+	List(entityType models.TranslationEntity, locale string) ([]models.Translation, error)
+	GetByLocale(locale string) ([]models.Translation, error)
+	ListSourceTexts() ([]models.MissingTranslation, error)
+}
+
+type translationRepository struct {
+	db *gorm.DB
+}
+
+func NewTranslationRepository(db *gorm.DB) TranslationRepository {
+	return &translationRepository{db: db}
+}
+
+// Upsert creates a translation or replaces the value of an existing one
+func (r *translationRepository) Upsert(translation *models.Translation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity_type"}, {Name: "entity_key"}, {Name: "field"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(translation).Error
+}
+
+func (r *translationRepository) GetByID(id uint) (*models.Translation, error) {
+	var translation models.Translation
+	err := r.db.First(&translation, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+func (r *translationRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Translation{}, id).Error
+}
+
+// List returns translations, optionally filtered by entity type and locale
+func (r *translationRepository) List(entityType models.TranslationEntity, locale string) ([]models.Translation, error) {
+	var translations []models.Translation
+	query := r.db.Model(&models.Translation{})
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if locale != "" {
+		query = query.Where("locale = ?", locale)
+	}
+	err := query.Order("entity_type, entity_key, field, locale").Find(&translations).Error
+	return translations, err
+}
+
+func (r *translationRepository) GetByLocale(locale string) ([]models.Translation, error) {
+	var translations []models.Translation
+	err := r.db.Where("locale = ?", locale).Find(&translations).Error
+	return translations, err
+}
+
+// ListSourceTexts returns every catalog value that can be translated, keyed the
+// same way translations are stored
+func (r *translationRepository) ListSourceTexts() ([]models.MissingTranslation, error) {
+	var sources []models.MissingTranslation
+
+	var aromas []models.AromaTag
+	if err := r.db.Order("slug").Find(&aromas).Error; err != nil {
+		return nil, fmt.Errorf("failed to list aroma tags: %w", err)
+	}
+	for _, aroma := range aromas {
+		sources = append(sources, models.MissingTranslation{
+			EntityType: models.TranslationEntityAromaTag,
+			EntityKey:  aroma.Slug,
+			Field:      models.TranslationFieldName,
+			SourceText: aroma.Name,
+		})
+	}
+
+	var notes []struct {
+		NoteKey  string
+		NoteName string
+	}
+	if err := r.db.Model(&models.Note{}).
+		Select("LOWER(note_name) AS note_key, MIN(note_name) AS note_name").
+		Group("LOWER(note_name)").
+		Order("note_key").
+		Scan(&notes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	for _, note := range notes {
+		sources = append(sources, models.MissingTranslation{
+			EntityType: models.TranslationEntityNote,
+			EntityKey:  note.NoteKey,
+			Field:      models.TranslationFieldName,
+			SourceText: note.NoteName,
+		})
+	}
+
+	var perfumes []models.Perfume
+	if err := r.db.Select("id", "description").
+		Where("description <> ''").
+		Order("id").
+		Find(&perfumes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list perfume descriptions: %w", err)
+	}
+	for _, perfume := range perfumes {
+		sources = append(sources, models.MissingTranslation{
+			EntityType: models.TranslationEntityPerfume,
+			EntityKey:  strconv.FormatUint(uint64(perfume.ID), 10),
+			Field:      models.TranslationFieldDescription,
+			SourceText: perfume.Description,
+		})
+	}
+
+	return sources, nil
+}
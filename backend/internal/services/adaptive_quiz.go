@@ -0,0 +1,435 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"perfume-website/internal/models"
+	"perfume-website/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrQuizSessionNotFound is returned when an adaptive quiz session ID does not exist
+	ErrQuizSessionNotFound = errors.New("quiz session not found")
+	// ErrQuizSessionCompleted is returned when answering a session that already stopped
+	ErrQuizSessionCompleted = errors.New("quiz session already completed")
+	// ErrInvalidQuizAnswer is returned when an answer is not for the pending question or not one of its options
+	ErrInvalidQuizAnswer = errors.New("invalid quiz answer")
+	// ErrQuizSessionConflict is returned when another answer to the session was saved first
+	ErrQuizSessionConflict = errors.New("quiz session was answered concurrently")
+)
+
+const (
+	// adaptiveCandidatePool is how many of the best-scoring perfumes a question has to split
+	adaptiveCandidatePool = 40
+	// adaptiveStableAnswers is how many answers in a row must leave the top
+	// results unchanged before the quiz stops
+	adaptiveStableAnswers = 2
+	// adaptiveMinAnswers keeps the quiz from stopping before it learned anything
+	adaptiveMinAnswers = 3
+	// minInformationGain is the gain in bits below which a question is not worth asking
+	minInformationGain = 0.01
+)
+
+// adaptiveOption is one answer to an adaptive question and how it changes the preferences
+type adaptiveOption struct {
+	value string
+	label string
+	apply func(*models.QuizPreferences)
+}
+
+// adaptiveQuestion asks for one group of QuizPreferences fields
+type adaptiveQuestion struct {
+	id      string
+	text    string
+	options []adaptiveOption
+}
+
+// flagOption sets one of the boolean preferences
+func flagOption(value, label string, field func(*models.QuizPreferences) *bool) adaptiveOption {
+	return adaptiveOption{value, label, func(p *models.QuizPreferences) { *field(p) = true }}
+}
+
+// choiceOption sets one of the string preferences to the option value
+func choiceOption(value, label string, field func(*models.QuizPreferences) *string) adaptiveOption {
+	return adaptiveOption{value, label, func(p *models.QuizPreferences) { *field(p) = value }}
+}
+
+// adaptiveQuestions are the questions the adaptive quiz picks from, covering
+// the same preferences as the fixed quiz form
+var adaptiveQuestions = []adaptiveQuestion{
+	{"scent_family", "Which scents draw you in most?", []adaptiveOption{
+		flagOption("light_fresh", "Light & fresh", func(p *models.QuizPreferences) *bool { return &p.LightFresh }),
+		flagOption("warm_spicy", "Warm & spicy", func(p *models.QuizPreferences) *bool { return &p.WarmSpicy }),
+		flagOption("sweet_gourmand", "Sweet & gourmand", func(p *models.QuizPreferences) *bool { return &p.SweetGourmand }),
+		flagOption("woody_earthy", "Woody & earthy", func(p *models.QuizPreferences) *bool { return &p.WoodyEarthy }),
+		flagOption("floral_romantic", "Floral & romantic", func(p *models.QuizPreferences) *bool { return &p.FloralRomantic }),
+		flagOption("citrus_energizing", "Citrus & energizing", func(p *models.QuizPreferences) *bool { return &p.CitrusEnergizing }),
+	}},
+	{"occasion", "When will you wear it most?", []adaptiveOption{
+		flagOption("daily_wear", "Every day", func(p *models.QuizPreferences) *bool { return &p.DailyWear }),
+		flagOption("work", "At work", func(p *models.QuizPreferences) *bool { return &p.Work }),
+		flagOption("dates", "On dates", func(p *models.QuizPreferences) *bool { return &p.Dates }),
+		flagOption("night_out", "Nights out", func(p *models.QuizPreferences) *bool { return &p.NightOut }),
+		flagOption("special_events", "Special events", func(p *models.QuizPreferences) *bool { return &p.SpecialEvents }),
+	}},
+	{"season", "Which season is it for?", []adaptiveOption{
+		flagOption("spring", "Spring", func(p *models.QuizPreferences) *bool { return &p.Spring }),
+		flagOption("summer", "Summer", func(p *models.QuizPreferences) *bool { return &p.Summer }),
+		flagOption("fall", "Fall", func(p *models.QuizPreferences) *bool { return &p.Fall }),
+		flagOption("winter", "Winter", func(p *models.QuizPreferences) *bool { return &p.Winter }),
+		flagOption("year_round", "All year", func(p *models.QuizPreferences) *bool { return &p.YearRound }),
+	}},
+	{"longevity", "How long should it last?", []adaptiveOption{
+		choiceOption("light", "A few hours", func(p *models.QuizPreferences) *string { return &p.Longevity }),
+		choiceOption("medium", "Most of the day", func(p *models.QuizPreferences) *string { return &p.Longevity }),
+		choiceOption("long", "All day and beyond", func(p *models.QuizPreferences) *string { return &p.Longevity }),
+	}},
+	{"sillage", "How far should it project?", []adaptiveOption{
+		choiceOption("subtle", "Close to the skin", func(p *models.QuizPreferences) *string { return &p.Sillage }),
+		choiceOption("moderate", "Noticeable", func(p *models.QuizPreferences) *string { return &p.Sillage }),
+		choiceOption("heavy", "Fills the room", func(p *models.QuizPreferences) *string { return &p.Sillage }),
+	}},
+	{"style", "Which style suits you?", []adaptiveOption{
+		flagOption("classic", "Classic", func(p *models.QuizPreferences) *bool { return &p.Classic }),
+		flagOption("modern", "Modern", func(p *models.QuizPreferences) *bool { return &p.Modern }),
+		flagOption("unique", "Unique", func(p *models.QuizPreferences) *bool { return &p.Unique }),
+		flagOption("safe_bet", "A safe bet", func(p *models.QuizPreferences) *bool { return &p.SafeBet }),
+	}},
+	{"price_range", "What would you like to spend?", []adaptiveOption{
+		choiceOption("budget", "Budget", func(p *models.QuizPreferences) *string { return &p.PriceRange }),
+		choiceOption("mid", "Mid-range", func(p *models.QuizPreferences) *string { return &p.PriceRange }),
+		choiceOption("luxury", "Luxury", func(p *models.QuizPreferences) *string { return &p.PriceRange }),
+		choiceOption("designer", "Designer", func(p *models.QuizPreferences) *string { return &p.PriceRange }),
+	}},
+}
+
+func findAdaptiveQuestion(id string) (*adaptiveQuestion, bool) {
+	for i := range adaptiveQuestions {
+		if adaptiveQuestions[i].id == id {
+			return &adaptiveQuestions[i], true
+		}
+	}
+	return nil, false
+}
+
+// AdaptiveQuizService runs the adaptive quiz: it asks one question at a time,
+// always the one whose answer best splits the current candidates, and stops
+// once answers no longer change the top results
+type AdaptiveQuizService struct {
+	quiz     *QuizService
+	sessions repositories.QuizSessionRepository
+}
+
+func NewAdaptiveQuizService(quiz *QuizService, sessions repositories.QuizSessionRepository) *AdaptiveQuizService {
+	return &AdaptiveQuizService{quiz: quiz, sessions: sessions}
+}
+
+// Start opens a session for the request. Its quiz preferences are the starting
+// point the answers add to; the other options apply to every ranking.
+func (s *AdaptiveQuizService) Start(req models.AdvancedRecommendationRequest) (*models.QuizSessionResponse, error) {
+	if req.Gift != nil {
+		return nil, fmt.Errorf("%w: gift mode does not use quiz preferences", ErrInvalidQuizRequest)
+	}
+	if algorithm := strings.ToLower(strings.TrimSpace(req.Algorithm)); algorithm != "" && algorithm != AlgorithmMultiFactor {
+		return nil, fmt.Errorf("%w: the adaptive quiz only supports the %s algorithm", ErrInvalidQuizRequest, AlgorithmMultiFactor)
+	}
+	if req.MaxResults <= 0 {
+		req.MaxResults = 6
+	}
+
+	id, err := newQuizSessionID()
+	if err != nil {
+		return nil, err
+	}
+	session := &models.QuizSession{
+		ID:      id,
+		Request: req,
+		Answers: []models.QuizAnswer{},
+		Status:  models.QuizSessionInProgress,
+	}
+	settings := s.quiz.settings.Current()
+	question, err := s.advance(session, settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Create(session); err != nil {
+		return nil, fmt.Errorf("failed to save quiz session: %w", err)
+	}
+	return s.respond(session, question, settings)
+}
+
+// Answer applies the answer to the pending question and picks the next one,
+// or completes the session with recommendations. Of two answers racing on one
+// session, the one saved second fails with ErrQuizSessionConflict.
+func (s *AdaptiveQuizService) Answer(id string, answer models.QuizAnswer) (*models.QuizSessionResponse, error) {
+	session, err := s.sessions.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuizSessionNotFound
+		}
+		return nil, err
+	}
+	if session.Status == models.QuizSessionCompleted {
+		return nil, ErrQuizSessionCompleted
+	}
+	if answer.QuestionID != session.PendingQuestion {
+		return nil, fmt.Errorf("%w: the pending question is '%s'", ErrInvalidQuizAnswer, session.PendingQuestion)
+	}
+	question, ok := findAdaptiveQuestion(answer.QuestionID)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown question '%s'", ErrInvalidQuizAnswer, answer.QuestionID)
+	}
+	value := strings.ToLower(strings.TrimSpace(answer.Answer))
+	if value != models.QuizNoPreference {
+		var option *adaptiveOption
+		for i := range question.options {
+			if question.options[i].value == value {
+				option = &question.options[i]
+			}
+		}
+		if option == nil {
+			return nil, fmt.Errorf("%w: unknown answer '%s' to '%s'", ErrInvalidQuizAnswer, answer.Answer, question.id)
+		}
+		option.apply(&session.Request.QuizPreferences)
+	}
+	session.Answers = append(session.Answers, models.QuizAnswer{QuestionID: question.id, Answer: value})
+
+	settings := s.quiz.settings.Current()
+	next, err := s.advance(session, settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Update(session); err != nil {
+		if errors.Is(err, repositories.ErrStaleQuizSession) {
+			return nil, ErrQuizSessionConflict
+		}
+		return nil, fmt.Errorf("failed to save quiz session: %w", err)
+	}
+	return s.respond(session, next, settings)
+}
+
+// advance re-ranks the session and either sets the next question or completes it
+func (s *AdaptiveQuizService) advance(session *models.QuizSession, settings models.RecommendationSettings) (*models.QuizQuestion, error) {
+	pool, top, err := s.rank(session.Request, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(session.Answers) > 0 && sameIDs(session.TopPerfumeIDs, top) {
+		session.StableAnswers++
+	} else {
+		session.StableAnswers = 0
+	}
+	session.TopPerfumeIDs = top
+
+	if session.StableAnswers >= adaptiveStableAnswers && len(session.Answers) >= adaptiveMinAnswers {
+		completeQuizSession(session, models.QuizStopStable)
+		return nil, nil
+	}
+	question, err := s.nextQuestion(session, pool, settings)
+	if err != nil {
+		return nil, err
+	}
+	if question == nil {
+		reason := models.QuizStopNoGain
+		if len(session.Answers) == len(adaptiveQuestions) {
+			reason = models.QuizStopExhausted
+		}
+		completeQuizSession(session, reason)
+		return nil, nil
+	}
+	session.PendingQuestion = question.ID
+	return question, nil
+}
+
+func completeQuizSession(session *models.QuizSession, reason string) {
+	session.Status = models.QuizSessionCompleted
+	session.StopReason = reason
+	session.PendingQuestion = ""
+}
+
+// rank scores the candidates of the request and returns the pool the next
+// question has to split, best first, and the IDs of the results the
+// recommendations would show, after the same diversity re-ranking
+func (s *AdaptiveQuizService) rank(req models.AdvancedRecommendationRequest, settings models.RecommendationSettings) ([]models.AdvancedRecommendationResult, []uint, error) {
+	scoring, err := s.quiz.newScoringContext(req, settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	perfumes, _, _, err := getCandidatePerfumes(s.quiz.catalog, req, scoring)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get candidate perfumes: %w", err)
+	}
+	results := s.quiz.scorePerfumes(perfumes, req, s.quiz.analyzePersonality(req.QuizPreferences), scoring)
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].OverallScore != results[j].OverallScore {
+			return results[i].OverallScore > results[j].OverallScore
+		}
+		return results[i].Perfume.ID < results[j].Perfume.ID
+	})
+	pool := append([]models.AdvancedRecommendationResult{}, results[:min(adaptiveCandidatePool, len(results))]...)
+
+	shown := rerankForDiversity(results, req.MaxResults, scoring.diversity, scoring.settings.Diversity)
+	top := make([]uint, 0, len(shown))
+	for _, result := range shown {
+		top = append(top, result.Perfume.ID)
+	}
+	return pool, top, nil
+}
+
+// nextQuestion returns the unanswered question with the highest information
+// gain over the pool, or nil when none reaches minInformationGain
+func (s *AdaptiveQuizService) nextQuestion(session *models.QuizSession, pool []models.AdvancedRecommendationResult, settings models.RecommendationSettings) (*models.QuizQuestion, error) {
+	answered := make(map[string]bool, len(session.Answers))
+	for _, answer := range session.Answers {
+		answered[answer.QuestionID] = true
+	}
+
+	var best *adaptiveQuestion
+	bestGain := 0.0
+	for i := range adaptiveQuestions {
+		question := &adaptiveQuestions[i]
+		if answered[question.id] {
+			continue
+		}
+		gain, err := s.informationGain(session.Request, question, pool, settings)
+		if err != nil {
+			return nil, err
+		}
+		if gain > bestGain {
+			best, bestGain = question, gain
+		}
+	}
+	if best == nil || bestGain < minInformationGain {
+		return nil, nil
+	}
+
+	options := make([]models.QuizQuestionOption, 0, len(best.options)+1)
+	for _, option := range best.options {
+		options = append(options, models.QuizQuestionOption{Value: option.value, Label: option.label})
+	}
+	options = append(options, models.QuizQuestionOption{Value: models.QuizNoPreference, Label: "No preference"})
+	return &models.QuizQuestion{ID: best.id, Text: best.text, Options: options, InformationGain: roundTo2(bestGain)}, nil
+}
+
+// informationGain is the mutual information, in bits, between the answer to the
+// question and which pool perfume is the user's best match. Each perfume is
+// weighted by its current score and is assumed to lead to the answers that
+// would rank it highest, with ties shared equally, so a question scores high
+// when its answers send the likely perfumes in different directions.
+func (s *AdaptiveQuizService) informationGain(req models.AdvancedRecommendationRequest, question *adaptiveQuestion, pool []models.AdvancedRecommendationResult, settings models.RecommendationSettings) (float64, error) {
+	if len(pool) == 0 {
+		return 0, nil
+	}
+	perfumes := make([]models.Perfume, len(pool))
+	weights := make([]float64, len(pool))
+	total := 0.0
+	for i, result := range pool {
+		perfumes[i] = result.Perfume
+		weights[i] = math.Max(result.OverallScore, 0)
+		total += weights[i]
+	}
+	for i := range weights {
+		if total > 0 {
+			weights[i] /= total
+		} else {
+			weights[i] = 1 / float64(len(weights))
+		}
+	}
+
+	// scores[o][i] is the score of perfume i if the user picks option o
+	scores := make([][]float64, len(question.options))
+	for o, option := range question.options {
+		answered := req
+		option.apply(&answered.QuizPreferences)
+		scoring, err := s.quiz.newScoringContext(answered, settings)
+		if err != nil {
+			return 0, err
+		}
+		results := s.quiz.scorePerfumes(perfumes, answered, s.quiz.analyzePersonality(answered.QuizPreferences), scoring)
+		scores[o] = make([]float64, len(results))
+		for i, result := range results {
+			scores[o][i] = result.OverallScore
+		}
+	}
+
+	answers := make([]float64, len(question.options))
+	conditional := 0.0
+	for i, weight := range weights {
+		best := math.Inf(-1)
+		for o := range scores {
+			best = math.Max(best, scores[o][i])
+		}
+		var ties []int
+		for o := range scores {
+			if scores[o][i] >= best-1e-9 {
+				ties = append(ties, o)
+			}
+		}
+		for _, o := range ties {
+			answers[o] += weight / float64(len(ties))
+		}
+		conditional += weight * math.Log2(float64(len(ties)))
+	}
+
+	entropy := 0.0
+	for _, p := range answers {
+		if p > 0 {
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return math.Max(entropy-conditional, 0), nil
+}
+
+func (s *AdaptiveQuizService) respond(session *models.QuizSession, question *models.QuizQuestion, settings models.RecommendationSettings) (*models.QuizSessionResponse, error) {
+	response := &models.QuizSessionResponse{
+		SessionID:     session.ID,
+		Status:        session.Status,
+		StopReason:    session.StopReason,
+		Answers:       session.Answers,
+		Question:      question,
+		TopPerfumeIDs: session.TopPerfumeIDs,
+		StableAnswers: session.StableAnswers,
+	}
+	if session.Status == models.QuizSessionCompleted {
+		recommendations, err := s.quiz.GetAdvancedRecommendationsWithSettings(session.Request, settings)
+		if err != nil {
+			return nil, err
+		}
+		response.Recommendations = recommendations
+	}
+	return response, nil
+}
+
+// sameIDs reports whether both lists hold the same perfumes, in any order
+func sameIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[uint]bool, len(a))
+	for _, id := range a {
+		seen[id] = true
+	}
+	for _, id := range b {
+		if !seen[id] {
+			return false
+		}
+	}
+	return true
+}
+
+func newQuizSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate quiz session ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
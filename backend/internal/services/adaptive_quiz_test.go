@@ -0,0 +1,55 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"perfume-website/internal/models"
+)
+
+func TestInformationGain(t *testing.T) {
+	fresh := models.Perfume{ID: 1, Name: "Fresh", AromaTags: []models.AromaTag{{ID: 1, Slug: "fresh", Name: "Fresh"}}}
+	aquatic := models.Perfume{ID: 2, Name: "Aquatic", AromaTags: []models.AromaTag{{ID: 2, Slug: "aquatic", Name: "Aquatic"}}}
+	spicy := models.Perfume{ID: 3, Name: "Spicy", AromaTags: []models.AromaTag{{ID: 3, Slug: "spicy", Name: "Spicy"}}}
+	pool := func(perfumes ...models.Perfume) []models.AdvancedRecommendationResult {
+		results := make([]models.AdvancedRecommendationResult, len(perfumes))
+		for i, perfume := range perfumes {
+			results[i] = models.AdvancedRecommendationResult{Perfume: perfume, OverallScore: 1}
+		}
+		return results
+	}
+
+	tests := []struct {
+		name     string
+		question string
+		pool     []models.AdvancedRecommendationResult
+		gain     float64
+	}{
+		// Each perfume wins under a different answer, so the answer names the best match
+		{"scent family splits fresh from spicy", "scent_family", pool(fresh, spicy), 1},
+		// Both perfumes win under the same answer, which tells them apart no better than guessing
+		{"scent family cannot split two fresh perfumes", "scent_family", pool(fresh, aquatic), 0},
+		// No perfume has a description, so no season answer changes any score
+		{"season answers change nothing", "season", pool(fresh, spicy), 0},
+		{"single perfume", "scent_family", pool(fresh), 0},
+		{"empty pool", "scent_family", nil, 0},
+	}
+
+	quiz := &AdaptiveQuizService{quiz: &QuizService{}}
+	settings := models.DefaultRecommendationSettings()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			question, ok := findAdaptiveQuestion(tt.question)
+			if !ok {
+				t.Fatalf("question %q not found", tt.question)
+			}
+			gain, err := quiz.informationGain(models.AdvancedRecommendationRequest{}, question, tt.pool, settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(gain-tt.gain) > 1e-9 {
+				t.Errorf("gain = %.4f bits, want %.4f", gain, tt.gain)
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"perfume-website/internal/models"
 	"perfume-website/internal/repositories"
@@ -15,16 +18,29 @@ type AromaService interface {
 	UpdateAroma(aroma *models.AromaTag) error
 	DeleteAroma(id uint) error
 	GetAromasBySlugs(slugs []string) ([]models.AromaTag, error)
+	GetAromaStats(minCount int64) (*models.AromaStatsResponse, error)
 }
 
 type aromaService struct {
-	aromaRepo repositories.AromaRepository
+	aromaRepo   repositories.AromaRepository
+	perfumeRepo repositories.PerfumeRepository
+	notifier    *CatalogNotifier
+
+	// statsCache holds the last computed usage statistics until the catalog changes
+	statsMu    sync.RWMutex
+	statsCache *models.AromaStatsResponse
 }
 
-func NewAromaService(aromaRepo repositories.AromaRepository) AromaService {
-	return &aromaService{
-		aromaRepo: aromaRepo,
+func NewAromaService(aromaRepo repositories.AromaRepository, perfumeRepo repositories.PerfumeRepository, notifier *CatalogNotifier) AromaService {
+	s := &aromaService{
+		aromaRepo:   aromaRepo,
+		perfumeRepo: perfumeRepo,
+		notifier:    notifier,
+	}
+	if notifier != nil {
+		notifier.Subscribe(s.invalidateStats)
 	}
+	return s
 }
 
 func (s *aromaService) CreateAroma(aroma *models.AromaTag) error {
@@ -33,7 +49,11 @@ func (s *aromaService) CreateAroma(aroma *models.AromaTag) error {
 	if err == nil && existingAroma != nil {
 		return fmt.Errorf("aroma with slug '%s' already exists", aroma.Slug)
 	}
-	return s.aromaRepo.Create(aroma)
+	if err := s.aromaRepo.Create(aroma); err != nil {
+		return err
+	}
+	s.notifier.Notify()
+	return nil
 }
 
 func (s *aromaService) GetAroma(id uint) (*models.AromaTag, error) {
@@ -54,13 +74,119 @@ func (s *aromaService) UpdateAroma(aroma *models.AromaTag) error {
 	if err == nil && existingAroma != nil && existingAroma.ID != aroma.ID {
 		return fmt.Errorf("aroma with slug '%s' already exists", aroma.Slug)
 	}
-	return s.aromaRepo.Update(aroma)
+	if err := s.aromaRepo.Update(aroma); err != nil {
+		return err
+	}
+	s.notifier.Notify()
+	return nil
 }
 
 func (s *aromaService) DeleteAroma(id uint) error {
-	return s.aromaRepo.Delete(id)
+	if err := s.aromaRepo.Delete(id); err != nil {
+		return err
+	}
+	s.notifier.Notify()
+	return nil
 }
 
 func (s *aromaService) GetAromasBySlugs(slugs []string) ([]models.AromaTag, error) {
 	return s.aromaRepo.GetBySlugs(slugs)
 }
+
+// GetAromaStats returns per-tag usage figures and the tag co-occurrence matrix.
+// Pairs seen on fewer than minCount perfumes are left out of the matrix.
+func (s *aromaService) GetAromaStats(minCount int64) (*models.AromaStatsResponse, error) {
+	stats, err := s.cachedStats()
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy so callers never mutate the cached slices
+	response := *stats
+	response.Tags = append([]models.AromaTagStats(nil), stats.Tags...)
+	response.Cooccurrences = make([]models.AromaCooccurrence, 0, len(stats.Cooccurrences))
+	for _, pair := range stats.Cooccurrences {
+		if pair.Count >= minCount {
+			response.Cooccurrences = append(response.Cooccurrences, pair)
+		}
+	}
+
+	return &response, nil
+}
+
+func (s *aromaService) cachedStats() (*models.AromaStatsResponse, error) {
+	s.statsMu.RLock()
+	cached := s.statsCache
+	s.statsMu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	if s.statsCache != nil {
+		return s.statsCache, nil
+	}
+
+	stats, err := s.computeStats()
+	if err != nil {
+		return nil, err
+	}
+	s.statsCache = stats
+	return stats, nil
+}
+
+func (s *aromaService) invalidateStats() {
+	s.statsMu.Lock()
+	s.statsCache = nil
+	s.statsMu.Unlock()
+}
+
+func (s *aromaService) computeStats() (*models.AromaStatsResponse, error) {
+	total, err := s.perfumeRepo.Count()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count perfumes: %w", err)
+	}
+
+	tags, err := s.aromaRepo.GetUsageStats()
+	if err != nil {
+		return nil, err
+	}
+
+	pairs, err := s.aromaRepo.GetCooccurrences()
+	if err != nil {
+		return nil, err
+	}
+
+	countByTag := make(map[uint]int64, len(tags))
+	for i := range tags {
+		if total > 0 {
+			tags[i].Share = float64(tags[i].PerfumeCount) / float64(total)
+		}
+		countByTag[tags[i].AromaTagID] = tags[i].PerfumeCount
+	}
+
+	for i := range pairs {
+		countA := countByTag[pairs[i].AromaTagID]
+		countB := countByTag[pairs[i].OtherAromaTagID]
+		if total == 0 || countA == 0 || countB == 0 {
+			continue
+		}
+		pairs[i].Support = float64(pairs[i].Count) / float64(total)
+		pairs[i].Lift = float64(pairs[i].Count) * float64(total) / (float64(countA) * float64(countB))
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Lift != pairs[j].Lift {
+			return pairs[i].Lift > pairs[j].Lift
+		}
+		return pairs[i].Count > pairs[j].Count
+	})
+
+	return &models.AromaStatsResponse{
+		TotalPerfumes: total,
+		Tags:          tags,
+		Cooccurrences: pairs,
+		GeneratedAt:   time.Now(),
+	}, nil
+}
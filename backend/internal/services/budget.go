@@ -0,0 +1,51 @@
+package services
+
+import (
+	"fmt"
+	"math"
+
+	"perfume-website/internal/models"
+)
+
+// budgetFit scores how well a price suits a band (0-1). Prices inside the band
+// score 1; outside it the score falls linearly and reaches 0 once the price is
+// tolerance times the band edge away. Being under budget costs half as much as
+// being over it.
+func budgetFit(price float64, band models.PriceBand, tolerance float64) float64 {
+	if band.Contains(price) {
+		return 1
+	}
+
+	var gap, edge float64
+	if price < band.Min {
+		gap, edge = (band.Min-price)/2, band.Min
+	} else {
+		gap, edge = price-band.Max, band.Max
+	}
+	if edge <= 0 || tolerance <= 0 {
+		return 0
+	}
+	return math.Max(0, 1-gap/(edge*tolerance))
+}
+
+// summarizeBudget counts the results inside the band and warns when fewer than
+// the configured minimum (or the requested number of results) fit
+func summarizeBudget(results []models.AdvancedRecommendationResult, scoring scoringContext, maxResults int) *models.BudgetSummary {
+	summary := &models.BudgetSummary{
+		PriceRange: scoring.priceRange,
+		Mode:       scoring.budgetMode,
+		MinPrice:   scoring.budgetBand.Min,
+		MaxPrice:   scoring.budgetBand.Max,
+	}
+	for _, result := range results {
+		if scoring.budgetBand.Contains(result.Perfume.Price) {
+			summary.FittingResults++
+		}
+	}
+
+	wanted := min(scoring.settings.Budget.MinFittingResults, maxResults)
+	if summary.FittingResults < wanted {
+		summary.Warning = fmt.Sprintf("Only %d of the recommended perfumes fit the '%s' price range; consider widening it.", summary.FittingResults, scoring.priceRange)
+	}
+	return summary
+}
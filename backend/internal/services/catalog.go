@@ -0,0 +1,176 @@
+package services
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"perfume-website/internal/models"
+	"perfume-website/internal/repositories"
+)
+
+// PerfumeFeatures is the precomputed feature vector of a perfume that content
+// similarity compares
+type PerfumeFeatures struct {
+	Accords map[uint]float64 // aroma tag ID -> strength, 0-1
+	Notes   map[string]bool  // note keys, see noteKey
+}
+
+func newPerfumeFeatures(perfume *models.Perfume) *PerfumeFeatures {
+	strengths := perfume.AccordStrengths()
+	features := &PerfumeFeatures{
+		Accords: make(map[uint]float64, len(perfume.AromaTags)),
+		Notes:   make(map[string]bool, len(perfume.Notes)),
+	}
+	for _, tag := range perfume.AromaTags {
+		features.Accords[tag.ID] = float64(strengths[tag.ID]) / models.MaxAccordStrength
+	}
+	for _, note := range perfume.Notes {
+		features.Notes[noteKey(note.NoteName)] = true
+	}
+	return features
+}
+
+// CatalogSnapshot is an immutable copy of the perfume catalog with its aroma
+// tags, notes and accords loaded. Snapshots are shared by concurrent requests,
+// so nothing reachable from one may be modified: copy a perfume by value and
+// replace, rather than edit, any slice it holds.
+type CatalogSnapshot struct {
+	Perfumes []models.Perfume // ordered by ID
+	BuiltAt  time.Time
+
+	index    map[uint]int
+	features []*PerfumeFeatures
+}
+
+func newCatalogSnapshot(perfumes []models.Perfume) *CatalogSnapshot {
+	slices.SortFunc(perfumes, func(a, b models.Perfume) int { return cmp.Compare(a.ID, b.ID) })
+	snapshot := &CatalogSnapshot{
+		Perfumes: perfumes,
+		BuiltAt:  time.Now(),
+		index:    make(map[uint]int, len(perfumes)),
+		features: make([]*PerfumeFeatures, len(perfumes)),
+	}
+	for i := range perfumes {
+		snapshot.index[perfumes[i].ID] = i
+		snapshot.features[i] = newPerfumeFeatures(&perfumes[i])
+	}
+	return snapshot
+}
+
+// Get returns the perfume with the given ID
+func (c *CatalogSnapshot) Get(id uint) (*models.Perfume, bool) {
+	i, ok := c.index[id]
+	if !ok {
+		return nil, false
+	}
+	return &c.Perfumes[i], true
+}
+
+// Features returns the precomputed features of a perfume in the snapshot, and
+// computes them for any other perfume
+func (c *CatalogSnapshot) Features(perfume *models.Perfume) *PerfumeFeatures {
+	if c != nil {
+		if i, ok := c.index[perfume.ID]; ok {
+			return c.features[i]
+		}
+	}
+	return newPerfumeFeatures(perfume)
+}
+
+// CatalogService keeps the in-memory catalog the recommenders read from, so
+// a recommendation request does not load every perfume from the database. The
+// snapshot is rebuilt after every catalog write and swapped in atomically;
+// readers keep the snapshot they started with.
+type CatalogService struct {
+	perfumeRepo repositories.PerfumeRepository
+
+	snapshot  atomic.Pointer[CatalogSnapshot]
+	rebuildMu sync.Mutex // serializes loads, so the newest write always lands last
+}
+
+// NewCatalogService creates the catalog. The first snapshot is built on first
+// use; notifier may be nil, which leaves refreshing to Rebuild and Start.
+func NewCatalogService(perfumeRepo repositories.PerfumeRepository, notifier *CatalogNotifier) *CatalogService {
+	s := &CatalogService{perfumeRepo: perfumeRepo}
+	if notifier != nil {
+		notifier.Subscribe(s.refresh)
+	}
+	return s
+}
+
+// Snapshot returns the current catalog, building it if needed
+func (s *CatalogService) Snapshot() (*CatalogSnapshot, error) {
+	if snapshot := s.snapshot.Load(); snapshot != nil {
+		return snapshot, nil
+	}
+
+	s.rebuildMu.Lock()
+	defer s.rebuildMu.Unlock()
+	// Another request may have built it while this one waited
+	if snapshot := s.snapshot.Load(); snapshot != nil {
+		return snapshot, nil
+	}
+	return s.load()
+}
+
+// Rebuild reloads the catalog from the database and swaps it in. When the
+// reload fails the stale snapshot is dropped, so the next request loads the
+// catalog again instead of serving perfumes that may no longer exist.
+func (s *CatalogService) Rebuild() (*CatalogSnapshot, error) {
+	s.rebuildMu.Lock()
+	defer s.rebuildMu.Unlock()
+	snapshot, err := s.load()
+	if err != nil {
+		s.snapshot.Store(nil)
+	}
+	return snapshot, err
+}
+
+// load must be called with rebuildMu held
+func (s *CatalogService) load() (*CatalogSnapshot, error) {
+	perfumes, err := s.perfumeRepo.GetAllWithRelations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalog: %w", err)
+	}
+	snapshot := newCatalogSnapshot(perfumes)
+	s.snapshot.Store(snapshot)
+	return snapshot, nil
+}
+
+// refresh rebuilds the catalog after a write
+func (s *CatalogService) refresh() {
+	if _, err := s.Rebuild(); err != nil {
+		log.Printf("Failed to rebuild catalog: %v", err)
+	}
+}
+
+// Start rebuilds the catalog on a fixed interval until the returned stop
+// function is called. This picks up changes made outside the server, such as
+// CSV imports.
+func (s *CatalogService) Start(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.refresh()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
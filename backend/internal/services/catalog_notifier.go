@@ -0,0 +1,38 @@
+package services
+
+import "sync"
+
+// CatalogNotifier fans out catalog change notifications to services that cache
+// data derived from perfumes, notes and aroma tags
+type CatalogNotifier struct {
+	mu        sync.RWMutex
+	listeners []func()
+}
+
+// NewCatalogNotifier creates a notifier with no listeners
+func NewCatalogNotifier() *CatalogNotifier {
+	return &CatalogNotifier{}
+}
+
+// Subscribe registers a callback that runs after every catalog write
+func (n *CatalogNotifier) Subscribe(listener func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.listeners = append(n.listeners, listener)
+}
+
+// Notify tells every listener that the catalog has changed
+func (n *CatalogNotifier) Notify() {
+	if n == nil {
+		return
+	}
+
+	n.mu.RLock()
+	listeners := make([]func(), len(n.listeners))
+	copy(listeners, n.listeners)
+	n.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+}
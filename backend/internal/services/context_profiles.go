@@ -0,0 +1,243 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"perfume-website/internal/models"
+)
+
+// wearProfile describes the perfumes that suit a time of day or an impression.
+// Aromas are matched against aroma tag slugs and names.
+type wearProfile struct {
+	aromas    []string
+	sillage   []string
+	longevity []string
+}
+
+var timeOfDayProfiles = map[string]wearProfile{
+	"morning": {
+		aromas:    []string{"citrus", "fresh", "aquatic", "green"},
+		sillage:   []string{"light", "medium"},
+		longevity: []string{"light", "medium"},
+	},
+	"afternoon": {
+		aromas:    []string{"floral", "fruity", "fresh", "citrus"},
+		sillage:   []string{"light", "medium"},
+		longevity: []string{"medium", "long"},
+	},
+	"evening": {
+		aromas:    []string{"oriental", "woody", "amber", "spicy", "oud"},
+		sillage:   []string{"medium", "heavy"},
+		longevity: []string{"long", "very long"},
+	},
+	"night": {
+		aromas:    []string{"oriental", "oud", "leather", "gourmand", "vanilla", "spicy"},
+		sillage:   []string{"heavy", "very heavy"},
+		longevity: []string{"long", "very long"},
+	},
+}
+
+var impressionProfiles = map[string]wearProfile{
+	"confident": {
+		aromas:    []string{"woody", "spicy", "leather", "citrus", "aromatic"},
+		sillage:   []string{"medium", "heavy"},
+		longevity: []string{"long", "very long"},
+	},
+	"elegant": {
+		aromas:    []string{"floral", "powdery", "musk", "chypre", "woody"},
+		sillage:   []string{"light", "medium"},
+		longevity: []string{"medium", "long"},
+	},
+	"playful": {
+		aromas:    []string{"fruity", "sweet", "gourmand", "citrus", "fresh"},
+		sillage:   []string{"light", "medium"},
+		longevity: []string{"light", "medium"},
+	},
+	"mysterious": {
+		aromas:    []string{"oriental", "oud", "incense", "amber", "smoky", "leather"},
+		sillage:   []string{"heavy", "very heavy"},
+		longevity: []string{"long", "very long"},
+	},
+	"professional": {
+		aromas:    []string{"fresh", "citrus", "aromatic", "woody", "green"},
+		sillage:   []string{"light", "medium"},
+		longevity: []string{"medium", "long"},
+	},
+	"romantic": {
+		aromas:    []string{"floral", "rose", "vanilla", "powdery", "musk", "sweet"},
+		sillage:   []string{"medium", "heavy"},
+		longevity: []string{"medium", "long"},
+	},
+}
+
+// profileFamily links a quiz scent preference to the aroma tags that satisfy it
+type profileFamily struct {
+	label  string
+	wanted func(models.QuizPreferences) bool
+	aromas []string
+}
+
+var profileFamilies = []profileFamily{
+	{"light & fresh", func(p models.QuizPreferences) bool { return p.LightFresh }, []string{"citrus", "fresh", "aquatic"}},
+	{"warm & spicy", func(p models.QuizPreferences) bool { return p.WarmSpicy }, []string{"spicy", "warm", "oriental"}},
+	{"sweet & gourmand", func(p models.QuizPreferences) bool { return p.SweetGourmand }, []string{"sweet", "vanilla", "gourmand"}},
+	{"woody & earthy", func(p models.QuizPreferences) bool { return p.WoodyEarthy }, []string{"woody", "earthy", "cedar"}},
+	{"floral & romantic", func(p models.QuizPreferences) bool { return p.FloralRomantic }, []string{"floral", "rose", "jasmine"}},
+	{"citrus & energizing", func(p models.QuizPreferences) bool { return p.CitrusEnergizing }, []string{"citrus", "bergamot", "lemon"}},
+}
+
+// seasonCues are description keywords that mark a perfume as suited to a season
+var seasonCues = map[string][]string{
+	"spring": {"fresh", "floral"},
+	"summer": {"light", "citrus", "aquatic"},
+	"fall":   {"warm", "spicy", "woody"},
+	"winter": {"rich", "deep", "oriental"},
+	// Tropical dry season: hot and sunny, heavy scents turn cloying
+	"dry": {"light", "citrus", "aquatic", "fresh"},
+	// Tropical wet season: humid and cooler, green and woody scents hold up
+	"wet": {"green", "woody", "earthy", "musk"},
+}
+
+// matchedCues returns the keywords that appear in the text
+func matchedCues(text string, cues []string) []string {
+	text = strings.ToLower(text)
+	var matched []string
+	for _, cue := range cues {
+		if strings.Contains(text, cue) {
+			matched = append(matched, cue)
+		}
+	}
+	return matched
+}
+
+// lookupWearProfile normalizes a request value and finds its profile. An empty
+// value returns no profile and no error.
+func lookupWearProfile(profiles map[string]wearProfile, field, value string) (string, *wearProfile, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return "", nil, nil
+	}
+	profile, ok := profiles[value]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: unknown %s '%s'", ErrInvalidQuizRequest, field, value)
+	}
+	return value, &profile, nil
+}
+
+// optionalWearProfile is lookupWearProfile for request values that only tune
+// the ranking: an unknown value returns no profile, so its component is left
+// out instead of failing the request, and the response warns that it was ignored.
+func (ctx *scoringContext) optionalWearProfile(profiles map[string]wearProfile, field, value string) (string, *wearProfile) {
+	name, profile, err := lookupWearProfile(profiles, field, value)
+	if err != nil {
+		ctx.warnings = append(ctx.warnings, fmt.Sprintf("Ignored unknown %s '%s'", field, value))
+		return "", nil
+	}
+	return name, profile
+}
+
+// calculateWearMatch scores a perfume against a wear profile (0-1): the
+// strongest matching accord counts for 60%, sillage for 25% and longevity for
+// 15%. component names the score component ("time_of_day" or "impression")
+// and value the requested option, for the explanations.
+func calculateWearMatch(perfume models.Perfume, profile *wearProfile, component, value string) (float64, []models.Explanation) {
+	var explanations []models.Explanation
+	aroma, accords := matchAccords(perfume, profile.aromas)
+
+	score := 0.6 * aroma
+	if aroma > 0 {
+		explanations = append(explanations, explain(component+".accords", map[string]any{"accords": accords, component: value}))
+	}
+	if matchesPerformance(profile.sillage, perfume.Sillage) {
+		score += 0.25
+		explanations = append(explanations, explain(component+".sillage", map[string]any{"sillage": perfume.Sillage, component: value}))
+	}
+	if matchesPerformance(profile.longevity, perfume.Longevity) {
+		score += 0.15
+		explanations = append(explanations, explain(component+".longevity", map[string]any{"longevity": perfume.Longevity, component: value}))
+	}
+	return score, explanations
+}
+
+// matchAccords returns the strength of the strongest accord (0-1) matching one
+// of the aromas by slug or name, and the names of all matching accords
+func matchAccords(perfume models.Perfume, aromas []string) (float64, []string) {
+	strengths := perfume.AccordStrengths()
+	best := 0.0
+	var accords []string
+	for _, tag := range perfume.AromaTags {
+		if containsFold(aromas, tag.Slug) || containsFold(aromas, tag.Name) {
+			best = math.Max(best, float64(strengths[tag.ID])/models.MaxAccordStrength)
+			accords = append(accords, tag.Name)
+		}
+	}
+	return best, accords
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// performanceLevels place longevity and sillage labels, from both perfumes
+// and quiz answers, on a 0-100 scale. The catalog mixes several vocabularies
+// (the seed data uses High, the CSV import Strong), so every comparison of
+// labels goes through this scale.
+var performanceLevels = map[string]int{
+	"light":       40,
+	"low":         40,
+	"subtle":      30,
+	"medium":      60,
+	"moderate":    60,
+	"long":        80,
+	"heavy":       80,
+	"high":        80,
+	"strong":      80,
+	"very long":   90,
+	"very heavy":  90,
+	"very high":   90,
+	"very strong": 90,
+}
+
+func performanceLevel(label string) (int, bool) {
+	level, ok := performanceLevels[strings.ToLower(strings.TrimSpace(label))]
+	return level, ok
+}
+
+// matchesPerformance reports whether a longevity or sillage label is at the
+// level of one of the wanted labels, whichever vocabulary each uses
+func matchesPerformance(wanted []string, label string) bool {
+	level, ok := performanceLevel(label)
+	if !ok {
+		return false
+	}
+	for _, candidate := range wanted {
+		if wantedLevel, known := performanceLevel(candidate); known && wantedLevel == level {
+			return true
+		}
+	}
+	return false
+}
+
+// sillageLevel ranks a sillage label from 1 (light) to 4 (very heavy) on the
+// shared performance scale, or 0 when the label is unknown
+func sillageLevel(label string) int {
+	level, ok := performanceLevel(label)
+	switch {
+	case !ok:
+		return 0
+	case level <= performanceLevels["light"]:
+		return 1
+	case level <= performanceLevels["medium"]:
+		return 2
+	case level <= performanceLevels["heavy"]:
+		return 3
+	}
+	return 4
+}
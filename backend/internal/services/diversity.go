@@ -0,0 +1,94 @@
+package services
+
+import (
+	"strings"
+
+	"perfume-website/internal/models"
+)
+
+// rerankForDiversity picks k results by maximal marginal relevance: each pick
+// maximises (1-diversity)*score - diversity*(similarity to the closest result
+// already picked). results must be sorted by score; RelevanceRank is set from
+// that order and Rank from the final one.
+func rerankForDiversity(results []models.AdvancedRecommendationResult, k int, diversity float64, weights models.DiversitySettings) []models.AdvancedRecommendationResult {
+	for i := range results {
+		results[i].RelevanceRank = i + 1
+	}
+	k = max(min(k, len(results)), 0)
+
+	var picked []models.AdvancedRecommendationResult
+	if diversity <= 0 {
+		picked = results[:k]
+	} else {
+		// closest[i] is the highest similarity between candidate i and any picked result
+		closest := make([]float64, len(results))
+		used := make([]bool, len(results))
+		picked = make([]models.AdvancedRecommendationResult, 0, k)
+		for len(picked) < k {
+			best, bestValue := -1, 0.0
+			for i := range results {
+				if used[i] {
+					continue
+				}
+				value := (1-diversity)*results[i].OverallScore - diversity*closest[i]
+				if best == -1 || value > bestValue {
+					best, bestValue = i, value
+				}
+			}
+
+			used[best] = true
+			picked = append(picked, results[best])
+			for i := range results {
+				if !used[i] {
+					similarity := diversitySimilarity(&results[i].Perfume, &results[best].Perfume, weights)
+					closest[i] = max(closest[i], similarity)
+				}
+			}
+		}
+	}
+
+	for i := range picked {
+		picked[i].Rank = i + 1
+		picked[i].DiversityAdjusted = picked[i].Rank != picked[i].RelevanceRank
+	}
+	return picked
+}
+
+// diversitySimilarity is how alike two perfumes look to a shopper (0-1): the
+// weighted sum of sharing a brand, sharing a category and overlapping aroma tags
+func diversitySimilarity(a, b *models.Perfume, weights models.DiversitySettings) float64 {
+	total := weights.Brand + weights.Category + weights.Aroma
+	if total <= 0 {
+		return 0
+	}
+
+	similarity := 0.0
+	if a.Brand != "" && strings.EqualFold(a.Brand, b.Brand) {
+		similarity += weights.Brand
+	}
+	if a.Category != "" && strings.EqualFold(a.Category, b.Category) {
+		similarity += weights.Category
+	}
+	similarity += weights.Aroma * aromaJaccard(a, b)
+
+	return similarity / total
+}
+
+// aromaJaccard is the share of aroma tags two perfumes have in common
+func aromaJaccard(a, b *models.Perfume) float64 {
+	tags := make(map[uint]bool, len(a.AromaTags))
+	for _, tag := range a.AromaTags {
+		tags[tag.ID] = true
+	}
+	shared := 0
+	for _, tag := range b.AromaTags {
+		if tags[tag.ID] {
+			shared++
+		}
+	}
+	union := len(a.AromaTags) + len(b.AromaTags) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
@@ -7,13 +7,16 @@ import (
 
 // EnhancedReviewService handles enhanced review business logic
 type EnhancedReviewService struct {
-	repo *models.EnhancedReviewRepositoryGORM
+	repo       *models.EnhancedReviewRepositoryGORM
+	similarity *ItemSimilarityService
 }
 
-// NewEnhancedReviewService creates a new enhanced review service
-func NewEnhancedReviewService(repo *models.EnhancedReviewRepositoryGORM) *EnhancedReviewService {
+// NewEnhancedReviewService creates a new enhanced review service. New reviews are
+// folded into the similarity model when one is given.
+func NewEnhancedReviewService(repo *models.EnhancedReviewRepositoryGORM, similarity *ItemSimilarityService) *EnhancedReviewService {
 	return &EnhancedReviewService{
-		repo: repo,
+		repo:       repo,
+		similarity: similarity,
 	}
 }
 
@@ -23,7 +26,13 @@ func (s *EnhancedReviewService) CreateReview(req *models.CreateEnhancedReviewReq
 	// For example: check if user already reviewed this perfume
 	// Or implement rate limiting, spam detection, etc.
 
-	return s.repo.Create(req)
+	review, err := s.repo.Create(req)
+	if err != nil {
+		return nil, err
+	}
+	s.similarity.AddReview(review)
+
+	return review, nil
 }
 
 // GetReviews gets enhanced reviews with filtering and sorting
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"math"
+
+	"perfume-website/internal/models"
+)
+
+// BuildEvaluationCases pairs each saved quiz with the perfumes its author rated
+// at least minRating. Quizzes and reviews are matched by email, falling back to
+// the name when there is none; quizzes without ground truth are skipped.
+func BuildEvaluationCases(quizzes []models.PersonalityQuiz, ratings []models.ItemRating, minRating int) []models.EvaluationCase {
+	relevant := make(map[string]map[uint]bool)
+	for _, rating := range ratings {
+		if rating.Rating < minRating || rating.Reviewer == "" {
+			continue
+		}
+		if relevant[rating.Reviewer] == nil {
+			relevant[rating.Reviewer] = make(map[uint]bool)
+		}
+		relevant[rating.Reviewer][rating.PerfumeID] = true
+	}
+
+	var cases []models.EvaluationCase
+	for _, quiz := range quizzes {
+		reviewer := reviewerKey(quiz.Email, quiz.Name)
+		if reviewer == "" || len(relevant[reviewer]) == 0 {
+			continue
+		}
+		cases = append(cases, models.EvaluationCase{Quiz: quiz, Reviewer: reviewer, Relevant: relevant[reviewer]})
+	}
+	return cases
+}
+
+// EvaluateRankings scores one strategy's ranked lists, given in the same order
+// as cases, against the ground truth. Only the first k perfumes of each list count.
+func EvaluateRankings(strategy string, cases []models.EvaluationCase, rankings [][]models.Perfume, k, catalogSize int, weights models.DiversitySettings) models.StrategyMetrics {
+	metrics := models.StrategyMetrics{Strategy: strategy, Cases: len(cases)}
+	if len(cases) == 0 || k <= 0 {
+		return metrics
+	}
+
+	recommended := make(map[uint]bool)
+	lists := 0
+	for i, c := range cases {
+		ranking := rankings[i]
+		if len(ranking) > k {
+			ranking = ranking[:k]
+		}
+
+		hits, dcg := 0, 0.0
+		for rank, perfume := range ranking {
+			recommended[perfume.ID] = true
+			if c.Relevant[perfume.ID] {
+				hits++
+				dcg += 1 / math.Log2(float64(rank+2))
+			}
+		}
+		ideal := 0.0
+		for rank := 0; rank < min(k, len(c.Relevant)); rank++ {
+			ideal += 1 / math.Log2(float64(rank+2))
+		}
+
+		metrics.Precision += float64(hits) / float64(k)
+		metrics.Recall += float64(hits) / float64(len(c.Relevant))
+		if ideal > 0 {
+			metrics.NDCG += dcg / ideal
+		}
+		if len(ranking) > 1 {
+			metrics.Diversity += intraListDiversity(ranking, weights)
+			lists++
+		}
+	}
+
+	n := float64(len(cases))
+	metrics.Precision /= n
+	metrics.Recall /= n
+	metrics.NDCG /= n
+	if lists > 0 {
+		metrics.Diversity /= float64(lists)
+	}
+	if catalogSize > 0 {
+		metrics.Coverage = float64(len(recommended)) / float64(catalogSize)
+	}
+	return metrics
+}
+
+// intraListDiversity is the mean dissimilarity between every pair of perfumes in a list
+func intraListDiversity(perfumes []models.Perfume, weights models.DiversitySettings) float64 {
+	total, pairs := 0.0, 0
+	for i := range perfumes {
+		for j := i + 1; j < len(perfumes); j++ {
+			total += 1 - diversitySimilarity(&perfumes[i], &perfumes[j], weights)
+			pairs++
+		}
+	}
+	if pairs == 0 {
+		return 0
+	}
+	return total / float64(pairs)
+}
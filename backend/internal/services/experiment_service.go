@@ -0,0 +1,382 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"regexp"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"perfume-website/internal/models"
+	"perfume-website/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidExperiment is returned when an experiment definition or status change is rejected
+	ErrInvalidExperiment = errors.New("invalid experiment")
+	// ErrExperimentNotFound is returned when an experiment ID does not exist
+	ErrExperimentNotFound = errors.New("experiment not found")
+	// ErrExperimentConflict is returned when starting an experiment on an endpoint that already runs one
+	ErrExperimentConflict = errors.New("another experiment is already running on this endpoint")
+)
+
+// experimentConfidenceZ is the normal quantile for the 95% intervals in reports
+const experimentConfidenceZ = 1.959964
+
+var experimentKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,99}$`)
+
+// VariantAssignment is the experiment variant serving one visitor's request
+type VariantAssignment struct {
+	Experiment  *models.Experiment
+	Variant     *models.ExperimentVariant
+	AnonymousID string
+}
+
+// Info is what the response tells the client about the variant
+func (a *VariantAssignment) Info() *models.ExperimentExposureInfo {
+	if a == nil {
+		return nil
+	}
+	return &models.ExperimentExposureInfo{Key: a.Experiment.Key, Variant: a.Variant.Name}
+}
+
+// ExperimentService assigns visitors to A/B variants of the recommendation
+// endpoints and reports how each variant converts. Running experiments are
+// kept in memory and swapped atomically when one starts or stops.
+type ExperimentService struct {
+	repo           repositories.ExperimentRepository
+	settings       *RecommendationSettingsService
+	perfumeService PerfumeService
+	quizService    *QuizService
+	running        atomic.Pointer[map[models.ExperimentEndpoint]*models.Experiment]
+}
+
+// NewExperimentService loads the running experiments
+func NewExperimentService(repo repositories.ExperimentRepository, settings *RecommendationSettingsService, perfumeService PerfumeService, quizService *QuizService) *ExperimentService {
+	s := &ExperimentService{repo: repo, settings: settings, perfumeService: perfumeService, quizService: quizService}
+	s.running.Store(&map[models.ExperimentEndpoint]*models.Experiment{})
+	if err := s.reloadRunning(); err != nil {
+		log.Printf("No experiments running: %v", err)
+	}
+	return s
+}
+
+func (s *ExperimentService) reloadRunning() error {
+	experiments, err := s.repo.GetRunning()
+	if err != nil {
+		return fmt.Errorf("failed to load running experiments: %w", err)
+	}
+	running := make(map[models.ExperimentEndpoint]*models.Experiment, len(experiments))
+	for i := range experiments {
+		running[experiments[i].Endpoint] = &experiments[i]
+	}
+	s.running.Store(&running)
+	return nil
+}
+
+// Assign returns the visitor's variant of the experiment running on the
+// endpoint, or nil when none runs. A visitor keeps their first variant.
+func (s *ExperimentService) Assign(endpoint models.ExperimentEndpoint, anonymousID string) (*VariantAssignment, error) {
+	experiment := (*s.running.Load())[endpoint]
+	if experiment == nil || anonymousID == "" {
+		return nil, nil
+	}
+
+	assignment, err := s.repo.GetAssignment(experiment.ID, anonymousID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = s.repo.CreateAssignment(&models.ExperimentAssignment{
+			ExperimentID: experiment.ID,
+			AnonymousID:  anonymousID,
+			Variant:      pickVariant(experiment, anonymousID).Name,
+		})
+		if err == nil {
+			// Read back so a concurrent request's assignment wins consistently
+			assignment, err = s.repo.GetAssignment(experiment.ID, anonymousID)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign experiment variant: %w", err)
+	}
+
+	variant, ok := experiment.Variant(assignment.Variant)
+	if !ok {
+		variant = pickVariant(experiment, anonymousID)
+	}
+	return &VariantAssignment{Experiment: experiment, Variant: variant, AnonymousID: anonymousID}, nil
+}
+
+// pickVariant hashes the visitor into a variant in proportion to the weights.
+// The experiment key salts the hash so experiments split traffic independently.
+func pickVariant(experiment *models.Experiment, anonymousID string) *models.ExperimentVariant {
+	total := 0
+	for _, variant := range experiment.Variants {
+		total += variant.Weight
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(experiment.Key + ":" + anonymousID))
+	bucket := int(hash.Sum64() % uint64(max(total, 1)))
+	for i, variant := range experiment.Variants {
+		if bucket < variant.Weight {
+			return &experiment.Variants[i]
+		}
+		bucket -= variant.Weight
+	}
+	return &experiment.Variants[0]
+}
+
+// QuizSettings returns the recommendation settings for a quiz request: the
+// active ones with the variant's overrides applied
+func (s *ExperimentService) QuizSettings(assignment *VariantAssignment) (models.RecommendationSettings, error) {
+	settings := s.settings.Current()
+	if assignment == nil || len(assignment.Variant.Settings) == 0 {
+		return settings, nil
+	}
+	if err := json.Unmarshal(assignment.Variant.Settings, &settings); err != nil {
+		return settings, fmt.Errorf("failed to apply variant %s settings: %w", assignment.Variant.Name, err)
+	}
+	return settings, nil
+}
+
+// LogExposure records that the visitor was served the given perfumes under their variant
+func (s *ExperimentService) LogExposure(assignment *VariantAssignment, perfumeIDs []uint) error {
+	if assignment == nil {
+		return nil
+	}
+	return s.repo.CreateExposure(&models.ExperimentExposure{
+		ExperimentID: assignment.Experiment.ID,
+		Variant:      assignment.Variant.Name,
+		AnonymousID:  assignment.AnonymousID,
+		PerfumeIDs:   perfumeIDs,
+	})
+}
+
+// RecordEvent attributes an outcome to every running experiment the visitor is
+// assigned to, and returns how many experiments it was recorded for
+func (s *ExperimentService) RecordEvent(anonymousID, eventType string, perfumeID uint) (int, error) {
+	if eventType != models.ExperimentEventClick && eventType != models.ExperimentEventReview {
+		return 0, fmt.Errorf("%w: event type must be '%s' or '%s'", ErrInvalidExperiment, models.ExperimentEventClick, models.ExperimentEventReview)
+	}
+	if anonymousID == "" {
+		return 0, nil
+	}
+
+	recorded := 0
+	for _, experiment := range *s.running.Load() {
+		assignment, err := s.repo.GetAssignment(experiment.ID, anonymousID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return recorded, fmt.Errorf("failed to look up experiment assignment: %w", err)
+		}
+		err = s.repo.CreateEvent(&models.ExperimentEvent{
+			ExperimentID: experiment.ID,
+			Variant:      assignment.Variant,
+			AnonymousID:  anonymousID,
+			Type:         eventType,
+			PerfumeID:    perfumeID,
+		})
+		if err != nil {
+			return recorded, fmt.Errorf("failed to record experiment event: %w", err)
+		}
+		recorded++
+	}
+	return recorded, nil
+}
+
+// Create validates and saves a new experiment as a draft
+func (s *ExperimentService) Create(req models.CreateExperimentRequest, createdBy string) (*models.Experiment, error) {
+	experiment := &models.Experiment{
+		Key:       req.Key,
+		Name:      req.Name,
+		Endpoint:  req.Endpoint,
+		Status:    models.ExperimentStatusDraft,
+		Variants:  req.Variants,
+		CreatedBy: createdBy,
+	}
+	for i := range experiment.Variants {
+		if experiment.Variants[i].Weight == 0 {
+			experiment.Variants[i].Weight = 1
+		}
+	}
+	if err := s.validate(experiment); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(experiment); err != nil {
+		return nil, fmt.Errorf("failed to save experiment: %w", err)
+	}
+	return experiment, nil
+}
+
+func (s *ExperimentService) validate(experiment *models.Experiment) error {
+	if !experimentKeyPattern.MatchString(experiment.Key) {
+		return fmt.Errorf("%w: key must be lower-case letters, digits, '-' or '_'", ErrInvalidExperiment)
+	}
+	if experiment.Endpoint != models.ExperimentEndpointQuiz && experiment.Endpoint != models.ExperimentEndpointRecommend {
+		return fmt.Errorf("%w: endpoint must be '%s' or '%s'", ErrInvalidExperiment, models.ExperimentEndpointQuiz, models.ExperimentEndpointRecommend)
+	}
+	if len(experiment.Variants) < 2 {
+		return fmt.Errorf("%w: at least two variants are required", ErrInvalidExperiment)
+	}
+
+	names := make(map[string]bool, len(experiment.Variants))
+	for _, variant := range experiment.Variants {
+		if variant.Name == "" || len(variant.Name) > 100 {
+			return fmt.Errorf("%w: variant names must be 1-100 characters", ErrInvalidExperiment)
+		}
+		if names[variant.Name] {
+			return fmt.Errorf("%w: duplicate variant '%s'", ErrInvalidExperiment, variant.Name)
+		}
+		names[variant.Name] = true
+		if variant.Weight < 0 {
+			return fmt.Errorf("%w: variant '%s' weight must not be negative", ErrInvalidExperiment, variant.Name)
+		}
+
+		switch experiment.Endpoint {
+		case models.ExperimentEndpointQuiz:
+			if variant.Strategy != "" && !slices.Contains(s.quizService.Algorithms(), variant.Strategy) {
+				return fmt.Errorf("%w: variant '%s': unknown quiz algorithm %s", ErrInvalidExperiment, variant.Name, variant.Strategy)
+			}
+			if len(variant.Settings) > 0 {
+				settings := s.settings.Current()
+				if err := json.Unmarshal(variant.Settings, &settings); err != nil {
+					return fmt.Errorf("%w: variant '%s' settings: %v", ErrInvalidExperiment, variant.Name, err)
+				}
+				if err := ValidateRecommendationSettings(settings); err != nil {
+					return fmt.Errorf("%w: variant '%s': %v", ErrInvalidExperiment, variant.Name, err)
+				}
+			}
+		case models.ExperimentEndpointRecommend:
+			if len(variant.Settings) > 0 {
+				return fmt.Errorf("%w: recommend variants configure a strategy, not settings", ErrInvalidExperiment)
+			}
+			if variant.Strategy != "" && !slices.Contains(s.perfumeService.ScoringStrategies(), variant.Strategy) {
+				return fmt.Errorf("%w: variant '%s': %v: %s", ErrInvalidExperiment, variant.Name, ErrUnknownStrategy, variant.Strategy)
+			}
+		}
+	}
+	return nil
+}
+
+// List returns every experiment, newest first
+func (s *ExperimentService) List() ([]models.Experiment, error) {
+	return s.repo.GetAll()
+}
+
+// Get returns one experiment
+func (s *ExperimentService) Get(id uint) (*models.Experiment, error) {
+	experiment, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExperimentNotFound
+	}
+	return experiment, err
+}
+
+// SetStatus starts a draft experiment or stops a running one. Stopped
+// experiments cannot be restarted, so their results are never mixed.
+func (s *ExperimentService) SetStatus(id uint, status models.ExperimentStatus) (*models.Experiment, error) {
+	experiment, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	switch {
+	case status == models.ExperimentStatusRunning && experiment.Status == models.ExperimentStatusDraft:
+		if other := (*s.running.Load())[experiment.Endpoint]; other != nil {
+			return nil, fmt.Errorf("%w: %s", ErrExperimentConflict, other.Key)
+		}
+		experiment.StartedAt = &now
+	case status == models.ExperimentStatusStopped && experiment.Status == models.ExperimentStatusRunning:
+		experiment.StoppedAt = &now
+	case status == experiment.Status:
+		return experiment, nil
+	default:
+		return nil, fmt.Errorf("%w: cannot change status from %s to %s", ErrInvalidExperiment, experiment.Status, status)
+	}
+
+	experiment.Status = status
+	if err := s.repo.Update(experiment); err != nil {
+		return nil, fmt.Errorf("failed to update experiment: %w", err)
+	}
+	if err := s.reloadRunning(); err != nil {
+		return nil, err
+	}
+	return experiment, nil
+}
+
+// Report compares each variant's conversion with the control (the first variant)
+func (s *ExperimentService) Report(id uint) (*models.ExperimentReport, error) {
+	experiment, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.repo.GetVariantStats(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load experiment stats: %w", err)
+	}
+	byVariant := make(map[string]models.VariantStats, len(stats))
+	for _, row := range stats {
+		byVariant[row.Variant] = row
+	}
+
+	report := &models.ExperimentReport{
+		Experiment:  *experiment,
+		Confidence:  0.95,
+		GeneratedAt: time.Now(),
+	}
+	control := byVariant[experiment.Variants[0].Name]
+	for i, variant := range experiment.Variants {
+		row := byVariant[variant.Name]
+		variantReport := models.VariantReport{
+			Variant:     variant.Name,
+			Control:     i == 0,
+			Users:       row.Users,
+			Exposures:   row.Exposures,
+			Conversions: make(map[string]models.ConversionStats),
+		}
+		for _, eventType := range []string{models.ExperimentEventClick, models.ExperimentEventReview} {
+			variantReport.Conversions[eventType] = conversionStats(row.Converted[eventType], row.Users, control.Converted[eventType], control.Users, i == 0)
+		}
+		report.Variants = append(report.Variants, variantReport)
+	}
+	return report, nil
+}
+
+// conversionStats computes a Wilson score interval for the variant's rate and
+// a normal-approximation interval for its difference from the control
+func conversionStats(converted, users, controlConverted, controlUsers int, isControl bool) models.ConversionStats {
+	stats := models.ConversionStats{Converted: converted}
+	if users == 0 {
+		return stats
+	}
+
+	z := experimentConfidenceZ
+	n := float64(users)
+	p := float64(converted) / n
+	denominator := 1 + z*z/n
+	centre := (p + z*z/(2*n)) / denominator
+	margin := z * math.Sqrt(p*(1-p)/n+z*z/(4*n*n)) / denominator
+	stats.Rate = p
+	stats.Lower = math.Max(0, centre-margin)
+	stats.Upper = math.Min(1, centre+margin)
+
+	if isControl || controlUsers == 0 {
+		return stats
+	}
+	n0 := float64(controlUsers)
+	p0 := float64(controlConverted) / n0
+	se := math.Sqrt(p*(1-p)/n + p0*(1-p0)/n0)
+	stats.Lift = p - p0
+	stats.LiftLower = stats.Lift - z*se
+	stats.LiftUpper = stats.Lift + z*se
+	stats.Significant = stats.LiftLower > 0 || stats.LiftUpper < 0
+	return stats
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"perfume-website/internal/models"
+)
+
+func TestConversionStatsWilsonBounds(t *testing.T) {
+	tests := []struct {
+		name         string
+		converted    int
+		users        int
+		lower, upper float64
+	}{
+		{"half", 50, 100, 0.4038, 0.5962},
+		{"none converted", 0, 10, 0, 0.2775},
+		{"all converted", 10, 10, 0.7225, 1},
+		{"small sample", 12, 40, 0.1807, 0.4543},
+		{"no users", 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := conversionStats(tt.converted, tt.users, 0, 0, true)
+			if !approxEqual(stats.Lower, tt.lower, 1e-4) || !approxEqual(stats.Upper, tt.upper, 1e-4) {
+				t.Errorf("interval = [%.4f, %.4f], want [%.4f, %.4f]", stats.Lower, stats.Upper, tt.lower, tt.upper)
+			}
+			if stats.Lower > stats.Rate+1e-9 || stats.Rate > stats.Upper+1e-9 {
+				t.Errorf("rate %.4f outside its interval [%.4f, %.4f]", stats.Rate, stats.Lower, stats.Upper)
+			}
+		})
+	}
+}
+
+func TestConversionStatsLiftInterval(t *testing.T) {
+	tests := []struct {
+		name                 string
+		converted, users     int
+		liftLower, liftUpper float64
+		significant          bool
+	}{
+		{"overlapping", 60, 100, -0.0372, 0.2372, false},
+		{"better", 70, 100, 0.0671, 0.3329, true},
+		{"worse", 30, 100, -0.3329, -0.0671, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := conversionStats(tt.converted, tt.users, 50, 100, false)
+			if !approxEqual(stats.Lift, float64(tt.converted)/float64(tt.users)-0.5, 1e-9) {
+				t.Errorf("lift = %.4f", stats.Lift)
+			}
+			if !approxEqual(stats.LiftLower, tt.liftLower, 1e-4) || !approxEqual(stats.LiftUpper, tt.liftUpper, 1e-4) {
+				t.Errorf("lift interval = [%.4f, %.4f], want [%.4f, %.4f]", stats.LiftLower, stats.LiftUpper, tt.liftLower, tt.liftUpper)
+			}
+			if stats.Significant != tt.significant {
+				t.Errorf("significant = %v, want %v", stats.Significant, tt.significant)
+			}
+		})
+	}
+
+	if stats := conversionStats(70, 100, 50, 100, true); stats.Lift != 0 || stats.Significant {
+		t.Errorf("control got a lift: %+v", stats)
+	}
+}
+
+func TestPickVariantFollowsWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int
+	}{
+		{"even", []int{1, 1}},
+		{"one to three", []int{1, 3}},
+		{"three way", []int{2, 5, 3}},
+		{"excluded variant", []int{1, 0, 1}},
+	}
+	const visitors = 20000
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			experiment := &models.Experiment{Key: "weights-" + tt.name}
+			total := 0
+			for i, weight := range tt.weights {
+				experiment.Variants = append(experiment.Variants, models.ExperimentVariant{Name: fmt.Sprintf("v%d", i), Weight: weight})
+				total += weight
+			}
+
+			counts := make(map[string]int)
+			for i := 0; i < visitors; i++ {
+				id := fmt.Sprintf("visitor-%d", i)
+				variant := pickVariant(experiment, id)
+				if again := pickVariant(experiment, id); again.Name != variant.Name {
+					t.Fatalf("%s assigned %s, then %s", id, variant.Name, again.Name)
+				}
+				counts[variant.Name]++
+			}
+			for _, variant := range experiment.Variants {
+				want := float64(variant.Weight) / float64(total)
+				got := float64(counts[variant.Name]) / visitors
+				if math.Abs(got-want) > 0.02 {
+					t.Errorf("%s got %.3f of visitors, want %.3f", variant.Name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func approxEqual(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"perfume-website/internal/models"
+)
+
+// explanationTemplate describes one explanation code
+type explanationTemplate struct {
+	component string
+	effect    string
+	template  string
+}
+
+// explanationTemplates is the catalog of explanation codes. Codes are part of
+// the API: clients key their translations on them, so never rename one. A
+// component that the endpoint does not score, such as price, marks an
+// informational explanation: price.value adds nothing to the score and only
+// shows among the match reasons.
+var explanationTemplates = map[string]explanationTemplate{
+	"profile.accord_match":   {"profile", models.ExplanationPositive, "Its {accords} accord matches your {preference} preference"},
+	"season.cue":             {"season", models.ExplanationPositive, "Described as {cues}, which suits {season}"},
+	"occasion.sillage":       {"occasion", models.ExplanationPositive, "{sillage} sillage is appropriate for {occasion}"},
+	"occasion.cue":           {"occasion", models.ExplanationPositive, "Described as {cues}, which suits {occasion}"},
+	"occasion.casual_fit":    {"occasion", models.ExplanationPositive, "Light and under {limit}, easy to wear every day"},
+	"occasion.special_price": {"occasion", models.ExplanationPositive, "At {price} it feels like a special-occasion treat"},
+	"performance.longevity":  {"performance", models.ExplanationPositive, "{longevity} longevity matches your {preference} longevity preference"},
+	"performance.sillage":    {"performance", models.ExplanationPositive, "{sillage} sillage matches your {preference} sillage preference"},
+	"uniqueness.premium":     {"uniqueness", models.ExplanationPositive, "A distinctive premium pick at {price}"},
+	"uniqueness.distinctive": {"uniqueness", models.ExplanationPositive, "A distinctive choice for your taste for unique scents"},
+	"uniqueness.safe_bet":    {"uniqueness", models.ExplanationPositive, "A crowd-pleasing choice at an accessible {price}"},
+	"notes.preferred":        {"notes", models.ExplanationPositive, "Contains {notes}, which you love"},
+	"notes.avoided":          {"notes", models.ExplanationNegative, "Contains {notes}, which you prefer to avoid"},
+	"community.co_rated":     {"community", models.ExplanationPositive, "Reviewers who loved your favourites also loved this ({similarity} similarity)"},
+	"community.content":      {"community", models.ExplanationPositive, "Shares accords and notes with perfumes you love ({similarity} similarity)"},
+	"budget.within":          {"budget", models.ExplanationPositive, "At {price} it is within your {price_range} range ({range})"},
+	"budget.outside":         {"budget", models.ExplanationNegative, "At {price} it is outside your {price_range} range ({range})"},
+	"time_of_day.accords":    {"time_of_day", models.ExplanationPositive, "{accords} accords suit {time_of_day} wear"},
+	"time_of_day.sillage":    {"time_of_day", models.ExplanationPositive, "{sillage} sillage suits {time_of_day} wear"},
+	"time_of_day.longevity":  {"time_of_day", models.ExplanationPositive, "{longevity} longevity suits {time_of_day} wear"},
+	"impression.accords":     {"impression", models.ExplanationPositive, "{accords} accords help you come across as {impression}"},
+	"impression.sillage":     {"impression", models.ExplanationPositive, "{sillage} sillage helps you come across as {impression}"},
+	"impression.longevity":   {"impression", models.ExplanationPositive, "{longevity} longevity helps you come across as {impression}"},
+	"weather.accords":        {"weather", models.ExplanationPositive, "{accords} accords suit {weather} weather"},
+	"weather.sillage":        {"weather", models.ExplanationPositive, "{sillage} sillage suits {weather} weather"},
+	"weather.longevity":      {"weather", models.ExplanationPositive, "{longevity} longevity suits {weather} weather"},
+	"weather.too_heavy":      {"weather", models.ExplanationNegative, "{sillage} sillage can turn cloying in {weather} weather"},
+	"price.value":            {"price", models.ExplanationPositive, "Great value at {price}"},
+	"feedback.disliked":      {"feedback", models.ExplanationNegative, "Ranked lower because you gave it a thumbs down"},
+	"layering.complement":    {"notes", models.ExplanationPositive, "Apply {first} first: its {base_notes} base anchors the {top_notes} top of {second}"},
+	"layering.bridge":        {"notes", models.ExplanationPositive, "Both contain {notes}, which ties them together"},
+	"layering.harmony":       {"harmony", models.ExplanationPositive, "Their scent families blend without clashing"},
+	"layering.clash":         {"harmony", models.ExplanationNegative, "{family} and {other_family} families tend to clash"},
+	"layering.balanced":      {"sillage", models.ExplanationPositive, "{upper_sillage} sillage over {lower_sillage} keeps the blend balanced"},
+	"layering.uneven":        {"sillage", models.ExplanationNegative, "{upper_sillage} sillage over {lower_sillage} is uneven, so one may drown out the other"},
+	"layering.overpowering":  {"sillage", models.ExplanationNegative, "Both project strongly ({upper_sillage} and {lower_sillage}), so apply lightly"},
+	"layering.vibe":          {"vibe", models.ExplanationPositive, "Together they suit a {vibe} vibe ({fit} fit)"},
+	"gift.crowd_pleaser":     {"consensus", models.ExplanationPositive, "{reviewers} reviewers rate it {rating}/5 and mostly agree"},
+	"gift.reviewed":          {"consensus", models.ExplanationPositive, "Rated {rating}/5 by {reviewers} reviewers"},
+	"gift.divisive":          {"consensus", models.ExplanationNegative, "Reviews are split ({rating}/5 from {reviewers} reviewers), so it is a riskier gift"},
+	"gift.unreviewed":        {"consensus", models.ExplanationNegative, "No reviews yet, so it is a riskier gift"},
+	"gift.poorly_rated":      {"consensus", models.ExplanationNegative, "Rated only {rating}/5 by {reviewers} reviewers"},
+	"gift.loved_co_rated":    {"favorites", models.ExplanationPositive, "Reviewers who love their favourites also love this ({similarity} similarity)"},
+	"gift.loved_content":     {"favorites", models.ExplanationPositive, "Shares accords and notes with perfumes they love ({similarity} similarity)"},
+	"gift.accords":           {"occasion", models.ExplanationPositive, "{accords} accords suit a {gift} gift"},
+	"gift.sillage":           {"occasion", models.ExplanationPositive, "{sillage} sillage suits a {gift} gift"},
+	"gift.longevity":         {"occasion", models.ExplanationPositive, "{longevity} longevity suits a {gift} gift"},
+	"gift.audience":          {"audience", models.ExplanationPositive, "Made for {audience}, as you asked"},
+	"gift.audience_unisex":   {"audience", models.ExplanationPositive, "A unisex scent anyone can wear"},
+	"gift.audience_mismatch": {"audience", models.ExplanationNegative, "Marketed to {audience}"},
+	"gift.age":               {"age", models.ExplanationPositive, "{accords} accords are popular with people {age_range}"},
+	"gift.considerate":       {"relationship", models.ExplanationPositive, "{sillage} sillage is a considerate choice for a {relationship}"},
+	"gift.too_bold":          {"relationship", models.ExplanationNegative, "{sillage} sillage may be too bold for a {relationship}"},
+	"gift.budget":            {"budget", models.ExplanationPositive, "At {price} it fits your budget of {budget}"},
+	"wardrobe.fills":         {"gap", models.ExplanationPositive, "Covers the {value} {dimension} gap in your collection ({fit} fit)"},
+	"wardrobe.distinct":      {"duplication", models.ExplanationPositive, "Unlike anything you own; the closest is {perfume} ({similarity} similarity)"},
+	"wardrobe.overlap":       {"duplication", models.ExplanationNegative, "Overlaps with {perfume}, which you own ({similarity} similarity)"},
+}
+
+var templateParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// explain builds an explanation from the catalog and renders its message
+func explain(code string, params map[string]any) models.Explanation {
+	entry, ok := explanationTemplates[code]
+	if !ok {
+		log.Printf("Unknown explanation code %q", code)
+	}
+
+	message := templateParam.ReplaceAllStringFunc(entry.template, func(placeholder string) string {
+		value, found := params[placeholder[1:len(placeholder)-1]]
+		if !found {
+			return placeholder
+		}
+		return formatExplanationParam(value)
+	})
+
+	return models.Explanation{
+		Code:      code,
+		Component: entry.component,
+		Effect:    entry.effect,
+		Template:  entry.template,
+		Params:    params,
+		Message:   message,
+	}
+}
+
+func formatExplanationParam(value any) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ", ")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// priceBandLabel formats a price band for explanations, e.g. "60-120" or "200+"
+func priceBandLabel(band models.PriceBand) string {
+	if band.Max == 0 {
+		return formatExplanationParam(band.Min) + "+"
+	}
+	return formatExplanationParam(band.Min) + "-" + formatExplanationParam(band.Max)
+}
+
+// roundTo2 keeps similarity scores readable in explanation parameters
+func roundTo2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestExplainCallSitesUseKnownCodes walks every explain call in the package so
+// that a code missing from explanationTemplates fails here instead of being
+// served with an empty message. Codes reach explain as literals, as variables
+// assigned literals, or as a calculateWearMatch component plus a suffix.
+func TestExplainCallSitesUseKnownCodes(t *testing.T) {
+	paths, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatalf("parse %s: %v", path, err)
+		}
+		files = append(files, file)
+	}
+
+	type callSite struct {
+		pos  token.Pos
+		code string
+	}
+	var sites []callSite
+	codeVariables := make(map[string]bool)
+	var suffixes []ast.Expr
+	var wearComponents []string
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			switch callee(call) {
+			case "explain":
+				switch arg := call.Args[0].(type) {
+				case *ast.BasicLit:
+					if code, ok := stringLiteral(arg); ok {
+						sites = append(sites, callSite{arg.Pos(), code})
+					}
+				case *ast.Ident:
+					codeVariables[arg.Name] = true
+				case *ast.BinaryExpr:
+					suffixes = append(suffixes, arg.Y)
+				default:
+					t.Errorf("%s: explain code %T is not checked", fset.Position(call.Pos()), arg)
+				}
+			case "calculateWearMatch":
+				if component, ok := stringLiteral(call.Args[2]); ok {
+					wearComponents = append(wearComponents, component)
+				}
+			}
+			return true
+		})
+	}
+
+	// Variables passed to explain are assigned their codes as literals
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			assign, ok := node.(*ast.AssignStmt)
+			if !ok || len(assign.Lhs) != len(assign.Rhs) {
+				return true
+			}
+			for i, lhs := range assign.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok || !codeVariables[ident.Name] {
+					continue
+				}
+				if code, ok := stringLiteral(assign.Rhs[i]); ok {
+					sites = append(sites, callSite{assign.Rhs[i].Pos(), code})
+				}
+			}
+			return true
+		})
+	}
+
+	for _, suffix := range suffixes {
+		value, ok := stringLiteral(suffix)
+		if !ok {
+			t.Errorf("%s: explain code suffix is not a literal", fset.Position(suffix.Pos()))
+			continue
+		}
+		for _, component := range wearComponents {
+			sites = append(sites, callSite{suffix.Pos(), component + value})
+		}
+	}
+
+	if len(sites) == 0 {
+		t.Fatal("no explain call sites found")
+	}
+	for _, site := range sites {
+		if _, known := explanationTemplates[site.code]; !known {
+			t.Errorf("%s: unknown explanation code %q", fset.Position(site.pos), site.code)
+		}
+	}
+}
+
+func TestExplainRendersParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		params  map[string]any
+		message string
+	}{
+		{"string list", "notes.preferred", map[string]any{"notes": []string{"Rose", "Oud"}}, "Contains Rose, Oud, which you love"},
+		{"float", "price.value", map[string]any{"price": 49.5}, "Great value at 49.5"},
+		{"missing param", "price.value", nil, "Great value at {price}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := explain(tt.code, tt.params).Message; got != tt.message {
+				t.Errorf("explain(%q) = %q, want %q", tt.code, got, tt.message)
+			}
+		})
+	}
+}
+
+func callee(call *ast.CallExpr) string {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	}
+	return ""
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
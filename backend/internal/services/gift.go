@@ -0,0 +1,517 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"perfume-website/internal/models"
+)
+
+const (
+	// giftConsensusPrior is how many reviews it takes before a perfume's
+	// reviews count for half of what a well-reviewed perfume's do
+	giftConsensusPrior = 3.0
+	// giftMaxRatingSpread is the rating standard deviation at which reviewers
+	// are considered to disagree completely
+	giftMaxRatingSpread = 1.5
+	// giftBoldSillageScore is the relationship score of a perfume projecting
+	// more than suits the relationship
+	giftBoldSillageScore = 0.3
+)
+
+// giftWeights balance the gift score components before normalization. Review
+// consensus dominates so that crowd-pleasers rank first.
+var giftWeights = map[string]float64{
+	"consensus":    0.35,
+	"favorites":    0.2,
+	"occasion":     0.15,
+	"audience":     0.1,
+	"age":          0.1,
+	"relationship": 0.1,
+}
+
+// giftComponents orders the gift score components, so scores are summed and
+// factors listed the same way every time
+var giftComponents = []string{"consensus", "favorites", "occasion", "audience", "age", "relationship"}
+
+// giftLeads open the gift note of a result, keyed by its strongest component
+var giftLeads = map[string]string{
+	"consensus":    "A crowd-pleasing safe bet",
+	"favorites":    "Close to what they already love",
+	"occasion":     "Well suited to the occasion",
+	"audience":     "Made for them",
+	"age":          "Popular with their age group",
+	"relationship": "A considerate choice",
+}
+
+var giftOccasionProfiles = map[string]wearProfile{
+	"birthday": {
+		aromas:    []string{"fruity", "floral", "sweet", "fresh", "citrus"},
+		sillage:   []string{"light", "medium", "heavy"},
+		longevity: []string{"medium", "long"},
+	},
+	"anniversary": {
+		aromas:    []string{"floral", "oriental", "amber", "vanilla", "rose", "musk"},
+		sillage:   []string{"medium", "heavy"},
+		longevity: []string{"long", "very long"},
+	},
+	"holiday": {
+		aromas:    []string{"spicy", "amber", "vanilla", "gourmand", "woody", "oriental"},
+		sillage:   []string{"medium", "heavy"},
+		longevity: []string{"long", "very long"},
+	},
+	"thank_you": {
+		aromas:    []string{"fresh", "citrus", "floral", "musk", "aquatic"},
+		sillage:   []string{"light", "medium"},
+		longevity: []string{"light", "medium"},
+	},
+	"graduation": {
+		aromas:    []string{"fresh", "citrus", "fruity", "aromatic", "woody"},
+		sillage:   []string{"light", "medium"},
+		longevity: []string{"medium", "long"},
+	},
+	"wedding": {
+		aromas:    []string{"floral", "musk", "powdery", "citrus", "rose", "jasmine"},
+		sillage:   []string{"light", "medium"},
+		longevity: []string{"long", "very long"},
+	},
+}
+
+// giftAgeRange lists the accords most often enjoyed in an age range
+type giftAgeRange struct {
+	label  string
+	aromas []string
+}
+
+var giftAgeRanges = map[string]giftAgeRange{
+	"under_25": {"under 25", []string{"fresh", "fruity", "sweet", "citrus", "gourmand", "vanilla"}},
+	"25_40":    {"aged 25 to 40", []string{"woody", "floral", "fresh", "aromatic", "citrus", "spicy"}},
+	"40_60":    {"aged 40 to 60", []string{"woody", "floral", "oriental", "chypre", "leather", "amber"}},
+	"over_60":  {"over 60", []string{"floral", "powdery", "chypre", "musk", "oriental", "woody"}},
+}
+
+// giftRelationshipSillage is the sillage that is considerate for a relationship;
+// the closer the relationship, the bolder the gift can be
+var giftRelationshipSillage = map[string][]string{
+	"partner":   {"light", "medium", "heavy", "very heavy"},
+	"family":    {"light", "medium", "heavy"},
+	"friend":    {"light", "medium", "heavy"},
+	"colleague": {"light", "medium"},
+}
+
+// giftAudiences maps gender words to the target audiences of the catalog
+var giftAudiences = map[string]string{
+	"men": "men", "man": "men", "male": "men", "masculine": "men", "him": "men", "homme": "men",
+	"women": "women", "woman": "women", "female": "women", "feminine": "women", "her": "women", "femme": "women",
+	"unisex": "unisex", "any": "unisex", "shared": "unisex",
+}
+
+// giftContext holds the validated recipient for one gift request
+type giftContext struct {
+	recipient       models.GiftRecipient
+	audience        string
+	ageRange        *giftAgeRange
+	relationship    string
+	occasion        string
+	occasionProfile *wearProfile
+	weights         map[string]float64 // normalized, with unused components dropped
+	dislikedIDs     map[uint]bool
+}
+
+func (s *QuizService) newGiftContext(req models.AdvancedRecommendationRequest) (giftContext, error) {
+	recipient := *req.Gift
+	ctx := giftContext{recipient: recipient}
+	if math.IsNaN(recipient.Budget) || recipient.Budget <= 0 {
+		return ctx, fmt.Errorf("%w: gift.budget must be a positive price", ErrInvalidQuizRequest)
+	}
+
+	if gender := strings.ToLower(strings.TrimSpace(recipient.Gender)); gender != "" {
+		audience, ok := giftAudiences[gender]
+		if !ok {
+			return ctx, fmt.Errorf("%w: unknown gift.gender '%s'", ErrInvalidQuizRequest, recipient.Gender)
+		}
+		ctx.audience = audience
+	}
+	if age := strings.ToLower(strings.TrimSpace(recipient.AgeRange)); age != "" {
+		ageRange, ok := giftAgeRanges[age]
+		if !ok {
+			return ctx, fmt.Errorf("%w: unknown gift.age_range '%s'", ErrInvalidQuizRequest, recipient.AgeRange)
+		}
+		ctx.ageRange = &ageRange
+	}
+	if relationship := strings.ToLower(strings.TrimSpace(recipient.Relationship)); relationship != "" {
+		if _, ok := giftRelationshipSillage[relationship]; !ok {
+			return ctx, fmt.Errorf("%w: unknown gift.relationship '%s'", ErrInvalidQuizRequest, recipient.Relationship)
+		}
+		ctx.relationship = relationship
+	}
+	var err error
+	if ctx.occasion, ctx.occasionProfile, err = lookupWearProfile(giftOccasionProfiles, "gift.occasion", recipient.Occasion); err != nil {
+		return ctx, err
+	}
+
+	if len(req.DislikedPerfumeIDs) > 0 {
+		ctx.dislikedIDs = make(map[uint]bool, len(req.DislikedPerfumeIDs))
+		for _, id := range req.DislikedPerfumeIDs {
+			ctx.dislikedIDs[id] = true
+		}
+	}
+
+	// Components only count when the request gave them something to measure
+	used := map[string]bool{
+		"consensus":    s.similarity != nil,
+		"favorites":    s.similarity != nil && len(recipient.FavoritePerfumeIDs) > 0,
+		"occasion":     ctx.occasionProfile != nil,
+		"audience":     ctx.audience != "",
+		"age":          ctx.ageRange != nil,
+		"relationship": ctx.relationship != "",
+	}
+	total := 0.0
+	for _, component := range giftComponents {
+		if used[component] {
+			total += giftWeights[component]
+		}
+	}
+	ctx.weights = make(map[string]float64)
+	for _, component := range giftComponents {
+		if used[component] {
+			ctx.weights[component] = giftWeights[component] / total
+		}
+	}
+	return ctx, nil
+}
+
+// getGiftRecommendations scores perfumes for a gift recipient instead of the
+// quiz taker. The budget is a hard limit and perfumes the recipient already
+// loves are left out.
+func (s *QuizService) getGiftRecommendations(req models.AdvancedRecommendationRequest, settings models.RecommendationSettings) (*models.AdvancedRecommendationResponse, error) {
+	gift, err := s.newGiftContext(req)
+	if err != nil {
+		return nil, err
+	}
+	diversity := settings.Diversity.Default
+	if req.Diversity != nil {
+		diversity = *req.Diversity
+		if math.IsNaN(diversity) || diversity < 0 || diversity > 1 {
+			return nil, fmt.Errorf("%w: diversity must be between 0 and 1", ErrInvalidQuizRequest)
+		}
+	}
+
+	candidateReq := req
+	candidateReq.LikedPerfumeIDs = append(append([]uint{}, req.LikedPerfumeIDs...), gift.recipient.FavoritePerfumeIDs...)
+	perfumes, excludedByNotes, excludedByIngredients, err := getCandidatePerfumes(s.catalog, candidateReq, scoringContext{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candidate perfumes: %w", err)
+	}
+
+	var results []models.AdvancedRecommendationResult
+	for _, perfume := range perfumes {
+		if perfume.Price > 0 && perfume.Price <= gift.recipient.Budget {
+			results = append(results, s.scoreGift(perfume, gift))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].OverallScore != results[j].OverallScore {
+			return results[i].OverallScore > results[j].OverallScore
+		}
+		return results[i].Perfume.ID < results[j].Perfume.ID
+	})
+	ranked := append([]models.AdvancedRecommendationResult{}, results...)
+	results = rerankForDiversity(results, req.MaxResults, diversity, settings.Diversity)
+
+	factors := make([]string, 0, len(gift.weights))
+	for _, component := range giftComponents {
+		if gift.weights[component] > 0 {
+			factors = append(factors, giftFactorNames[component])
+		}
+	}
+
+	return &models.AdvancedRecommendationResponse{
+		Results:             results,
+		PersonalityAnalysis: describeGiftRecipient(gift),
+		RecommendationLogic: models.RecommendationLogic{
+			Algorithm:          AlgorithmGift,
+			Version:            "Gift Recommendation v1.0",
+			FactorsConsidered:  factors,
+			Weighting:          gift.weights,
+			Diversity:          diversity,
+			ProcessDescription: "Gift mode scores perfumes for the recipient rather than for you, favouring well-reviewed crowd-pleasers that reviewers agree on, within your budget.",
+		},
+		Tips:                  giftTips(gift),
+		Alternatives:          giftAlternatives(ranked, results),
+		ExcludedByNotes:       excludedByNotes,
+		ExcludedByIngredients: excludedByIngredients,
+		Budget: &models.BudgetSummary{
+			Mode:           models.BudgetModeStrict,
+			MaxPrice:       gift.recipient.Budget,
+			FittingResults: len(results),
+		},
+	}, nil
+}
+
+var giftFactorNames = map[string]string{
+	"consensus":    "Review Consensus",
+	"favorites":    "Their Favourites",
+	"occasion":     "Gift Occasion",
+	"audience":     "Target Audience",
+	"age":          "Age Range",
+	"relationship": "Relationship",
+}
+
+func (s *QuizService) scoreGift(perfume models.Perfume, gift giftContext) models.AdvancedRecommendationResult {
+	components := make(map[string]float64, len(gift.weights))
+	var explanations []models.Explanation
+	var communityMatch float64
+	var communitySource string
+
+	if _, ok := gift.weights["consensus"]; ok {
+		count, mean, stddev := s.similarity.RatingSummary(perfume.ID)
+		components["consensus"] = giftConsensus(count, mean, stddev)
+		params := map[string]any{"reviewers": count, "rating": math.Round(mean*10) / 10}
+		switch {
+		case count == 0:
+			explanations = append(explanations, explain("gift.unreviewed", nil))
+		case mean < neutralRating:
+			explanations = append(explanations, explain("gift.poorly_rated", params))
+		case stddev > 1.2:
+			explanations = append(explanations, explain("gift.divisive", params))
+		case mean >= 4 && stddev <= 1:
+			explanations = append(explanations, explain("gift.crowd_pleaser", params))
+		default:
+			explanations = append(explanations, explain("gift.reviewed", params))
+		}
+	}
+
+	if _, ok := gift.weights["favorites"]; ok {
+		communityMatch, communitySource = s.similarity.CommunityScore(&perfume, gift.recipient.FavoritePerfumeIDs)
+		components["favorites"] = communityMatch
+		if communityMatch > 0 {
+			code := "gift.loved_content"
+			if communitySource == models.SimilaritySourceCommunity {
+				code = "gift.loved_co_rated"
+			}
+			explanations = append(explanations, explain(code, map[string]any{"similarity": roundTo2(communityMatch)}))
+		}
+	}
+
+	if gift.occasionProfile != nil {
+		var why []models.Explanation
+		components["occasion"], why = calculateWearMatch(perfume, gift.occasionProfile, "gift", strings.ReplaceAll(gift.occasion, "_", " "))
+		explanations = append(explanations, why...)
+	}
+
+	if gift.audience != "" {
+		fit, audience := giftAudienceFit(perfume.TargetAudience, gift.audience)
+		components["audience"] = fit
+		switch {
+		case audience == "":
+		case audience == gift.audience:
+			explanations = append(explanations, explain("gift.audience", map[string]any{"audience": perfume.TargetAudience}))
+		case audience == "unisex":
+			explanations = append(explanations, explain("gift.audience_unisex", nil))
+		default:
+			explanations = append(explanations, explain("gift.audience_mismatch", map[string]any{"audience": perfume.TargetAudience}))
+		}
+	}
+
+	if gift.ageRange != nil {
+		fit, accords := matchAccords(perfume, gift.ageRange.aromas)
+		components["age"] = fit
+		if fit > 0 {
+			explanations = append(explanations, explain("gift.age", map[string]any{"accords": accords, "age_range": gift.ageRange.label}))
+		}
+	}
+
+	if gift.relationship != "" {
+		params := map[string]any{"sillage": perfume.Sillage, "relationship": gift.relationship}
+		switch {
+		case perfume.Sillage == "":
+			components["relationship"] = 0.5
+		case matchesPerformance(giftRelationshipSillage[gift.relationship], perfume.Sillage):
+			components["relationship"] = 1
+			explanations = append(explanations, explain("gift.considerate", params))
+		default:
+			components["relationship"] = giftBoldSillageScore
+			explanations = append(explanations, explain("gift.too_bold", params))
+		}
+	}
+
+	explanations = append(explanations, explain("gift.budget", map[string]any{"price": perfume.Price, "budget": gift.recipient.Budget}))
+
+	overallScore := 0.0
+	breakdown := make(map[string]float64, len(gift.weights))
+	for _, component := range giftComponents {
+		if weight, ok := gift.weights[component]; ok {
+			breakdown[component] = components[component] * weight
+			overallScore += breakdown[component]
+		}
+	}
+	var warnings []string
+	if gift.dislikedIDs[perfume.ID] {
+		breakdown["feedback"] = -overallScore * dislikedDemotion
+		overallScore += breakdown["feedback"]
+		warnings = append(warnings, "You gave this a thumbs down before")
+		explanations = append(explanations, explain("feedback.disliked", nil))
+	}
+	for _, explanation := range explanations {
+		if explanation.Effect == models.ExplanationNegative && explanation.Code != "feedback.disliked" {
+			warnings = append(warnings, explanation.Message)
+		}
+	}
+
+	lead, note := giftNote(breakdown, explanations)
+	var bestFor []string
+	if gift.occasion != "" {
+		bestFor = []string{strings.ReplaceAll(gift.occasion, "_", " ") + " gift"}
+	}
+
+	return models.AdvancedRecommendationResult{
+		Perfume:         perfume,
+		OverallScore:    overallScore,
+		CommunityMatch:  communityMatch,
+		CommunitySource: communitySource,
+		BudgetFit:       1,
+		ScoreBreakdown:  breakdown,
+		Explanations:    explanations,
+		MatchReasons:    []string{lead},
+		Warnings:        warnings,
+		BestFor:         bestFor,
+		Longevity:       perfume.Longevity,
+		Projection:      perfume.Sillage,
+		GiftNote:        note,
+		Confidence:      math.Min(overallScore+0.1, 1.0),
+	}
+}
+
+// giftConsensus scores how safely a perfume pleases (0-1): a high mean rating
+// that reviewers agree on, trusted more as the number of reviewers grows
+func giftConsensus(count int, mean, stddev float64) float64 {
+	if count == 0 {
+		return 0
+	}
+	rating := (mean - 1) / 4
+	agreement := 1 - math.Min(stddev/giftMaxRatingSpread, 1)
+	confidence := float64(count) / (float64(count) + giftConsensusPrior)
+	return rating * (0.5 + 0.5*agreement) * confidence
+}
+
+// giftAudienceFit scores a perfume's target audience against the recipient's
+// (0-1) and returns the perfume's normalized audience, "" when unknown
+func giftAudienceFit(targetAudience, wanted string) (float64, string) {
+	audience := giftAudiences[strings.ToLower(strings.TrimSpace(targetAudience))]
+	switch {
+	case audience == "":
+		return 0.5, ""
+	case audience == wanted:
+		return 1, audience
+	case audience == "unisex":
+		return 0.8, audience
+	case wanted == "unisex":
+		return 0.5, audience
+	default:
+		return 0, audience
+	}
+}
+
+// giftNote explains a gift result in a few sentences: a lead for its strongest
+// component, the positive facts behind its two strongest components and the
+// price against the budget
+func giftNote(breakdown map[string]float64, explanations []models.Explanation) (string, string) {
+	components := make([]string, 0, len(breakdown))
+	for component, contribution := range breakdown {
+		if contribution > 0 {
+			components = append(components, component)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if breakdown[components[i]] != breakdown[components[j]] {
+			return breakdown[components[i]] > breakdown[components[j]]
+		}
+		return components[i] < components[j]
+	})
+
+	lead := "A gift within your budget"
+	if len(components) > 0 {
+		lead = giftLeads[components[0]]
+	}
+	sentences := []string{lead}
+	for _, component := range components[:min(len(components), 2)] {
+		for _, explanation := range explanations {
+			if explanation.Component == component && explanation.Effect == models.ExplanationPositive {
+				sentences = append(sentences, explanation.Message)
+				break
+			}
+		}
+	}
+	for _, explanation := range explanations {
+		if explanation.Code == "gift.budget" {
+			sentences = append(sentences, explanation.Message)
+		}
+	}
+	return lead, strings.Join(sentences, ". ") + "."
+}
+
+func describeGiftRecipient(gift giftContext) models.PersonalityAnalysis {
+	recipient := "someone special"
+	if gift.relationship != "" {
+		recipient = "your " + gift.relationship
+	}
+	var traits []string
+	if gift.ageRange != nil {
+		traits = append(traits, capitalize(gift.ageRange.label))
+	}
+	if gift.audience != "" {
+		traits = append(traits, capitalize(gift.audience))
+	}
+	if gift.occasion != "" {
+		traits = append(traits, capitalize(strings.ReplaceAll(gift.occasion, "_", " ")))
+	}
+	return models.PersonalityAnalysis{
+		ScentPersonality:    "A gift for " + recipient,
+		KeyTraits:           traits,
+		StyleDescription:    "These picks are chosen for the recipient, not for you: well-reviewed perfumes that most people enjoy.",
+		RecommendationStyle: fmt.Sprintf("Safe bets for %s, all within your budget of %s.", recipient, formatExplanationParam(gift.recipient.Budget)),
+	}
+}
+
+func capitalize(text string) string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return text
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func giftTips(gift giftContext) []string {
+	tips := []string{"Include a gift receipt so they can exchange it if the scent is not for them"}
+	if len(gift.recipient.FavoritePerfumeIDs) == 0 {
+		tips = append(tips, "Knowing one perfume they already love makes the suggestions much more personal")
+	}
+	if gift.relationship == "colleague" {
+		tips = append(tips, "Keep workplace gifts light and understated")
+	}
+	tips = append(tips, "A travel size or discovery set lowers the risk with a scent they have never tried")
+	return tips
+}
+
+// giftAlternatives returns a few of the best perfumes that did not make the results
+func giftAlternatives(ranked, results []models.AdvancedRecommendationResult) []models.Perfume {
+	shown := make(map[uint]bool, len(results))
+	for _, result := range results {
+		shown[result.Perfume.ID] = true
+	}
+	alternatives := []models.Perfume{}
+	for _, result := range ranked {
+		if len(alternatives) == 3 {
+			break
+		}
+		if !shown[result.Perfume.ID] {
+			alternatives = append(alternatives, result.Perfume)
+		}
+	}
+	return alternatives
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"strings"
+
+	"perfume-website/internal/models"
+)
+
+// ingredientSynonyms group the names one material is listed under, so
+// excluding any of them excludes them all. They cover the common allergens
+// (including their INCI names) and materials users most often react to.
+var ingredientSynonyms = [][]string{
+	{"oakmoss", "oak moss", "mousse de chene", "mousse de chêne", "evernia prunastri"},
+	{"treemoss", "tree moss", "evernia furfuracea"},
+	{"cinnamon", "cassia", "cinnamal", "cinnamyl alcohol"},
+	{"clove", "eugenol", "isoeugenol"},
+	{"musk", "white musk", "musks", "galaxolide", "tonalide", "habanolide", "muscone", "ethylene brassylate", "ambrettolide"},
+	{"ylang-ylang", "ylang ylang", "ylang"},
+	{"tonka", "tonka bean", "coumarin"},
+	{"peru balsam", "balsam of peru", "myroxylon pereirae"},
+	{"benzoin", "styrax benzoin"},
+	{"lavender", "lavandin"},
+	{"jasmine", "jasmin", "jasmine sambac"},
+	{"patchouli", "patchouly"},
+	{"vetiver", "vetyver"},
+	{"oud", "oudh", "agarwood", "aoud"},
+	{"labdanum", "cistus", "rockrose"},
+	{"vanilla", "vanillin"},
+	{"citral", "lemongrass", "litsea cubeba"},
+}
+
+// ingredientExclusion removes perfumes that contain an excluded note or aroma.
+// Terms are matched like note preferences, as whole words of the note name, and
+// against aroma tag slugs and names.
+type ingredientExclusion struct {
+	terms []string
+}
+
+// newIngredientExclusion normalizes the requested terms and adds their synonyms
+func newIngredientExclusion(terms []string) ingredientExclusion {
+	normalized := normalizeNoteTerms(terms)
+	expanded := append([]string{}, normalized...)
+	for _, term := range normalized {
+		for _, group := range ingredientSynonyms {
+			if containsFold(group, term) {
+				expanded = append(expanded, group...)
+			}
+		}
+	}
+	return ingredientExclusion{terms: normalizeNoteTerms(expanded)}
+}
+
+func (e ingredientExclusion) empty() bool {
+	return len(e.terms) == 0
+}
+
+// excludes reports whether the perfume contains one of the excluded terms
+func (e ingredientExclusion) excludes(perfume *models.Perfume) bool {
+	if e.empty() {
+		return false
+	}
+	if containsAnyNote(*perfume, e.terms) {
+		return true
+	}
+	for _, tag := range perfume.AromaTags {
+		if containsFold(e.terms, tag.Slug) || containsFold(e.terms, strings.TrimSpace(tag.Name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the perfumes that contain none of the excluded terms and how
+// many were removed. It allocates a new slice, so perfumes may be a shared
+// catalog snapshot.
+func (e ingredientExclusion) filter(perfumes []models.Perfume) ([]models.Perfume, int) {
+	if e.empty() {
+		return perfumes, 0
+	}
+	kept := make([]models.Perfume, 0, len(perfumes))
+	for i := range perfumes {
+		if !e.excludes(&perfumes[i]) {
+			kept = append(kept, perfumes[i])
+		}
+	}
+	return kept, len(perfumes) - len(kept)
+}
+
+// excludedIDs returns the IDs of the excluded perfumes
+func (e ingredientExclusion) excludedIDs(perfumes []models.Perfume) []uint {
+	var ids []uint
+	for i := range perfumes {
+		if e.excludes(&perfumes[i]) {
+			ids = append(ids, perfumes[i].ID)
+		}
+	}
+	return ids
+}
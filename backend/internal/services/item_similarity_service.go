@@ -0,0 +1,457 @@
+package services
+
+import (
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"perfume-website/internal/models"
+	"perfume-website/internal/repositories"
+)
+
+const (
+	// neutralRating is the midpoint of the 1-5 scale. Ratings are centred on it
+	// rather than on each reviewer's mean because most reviewers rate only a few
+	// perfumes, which makes per-user means unreliable.
+	neutralRating = 3.0
+	// minCoRaters is how many reviewers must have rated both perfumes before
+	// their community similarity is trusted
+	minCoRaters = 2
+	// similarityShrinkage damps similarities backed by few co-raters
+	similarityShrinkage = 3.0
+
+	DefaultAlsoLovedLimit = 6
+	MaxAlsoLovedLimit     = 24
+
+	DefaultAlternativesLimit = 6
+	MaxAlternativesLimit     = 24
+	// minAlternativeSimilarity keeps budget alternatives credible: below it a
+	// cheaper perfume shares too little with the reference to be called alike
+	minAlternativeSimilarity = 0.3
+)
+
+// itemNeighbor is the community similarity between two perfumes
+type itemNeighbor struct {
+	similarity float64
+	coRaters   int
+}
+
+// ItemSimilarityService is an item-to-item collaborative filter built from the
+// overall ratings in enhanced reviews. Perfumes without enough co-ratings fall
+// back to content similarity over accords and notes.
+type ItemSimilarityService struct {
+	ratingRepo repositories.ReviewRatingRepository
+	catalog    *CatalogService
+
+	mu        sync.RWMutex
+	ratings   map[uint]map[string]float64 // perfume -> reviewer -> centred rating
+	userItems map[string]map[uint]float64 // reviewer -> perfume -> centred rating
+	neighbors map[uint]map[uint]itemNeighbor
+	builtAt   time.Time
+}
+
+func NewItemSimilarityService(ratingRepo repositories.ReviewRatingRepository, catalog *CatalogService) *ItemSimilarityService {
+	return &ItemSimilarityService{
+		ratingRepo: ratingRepo,
+		catalog:    catalog,
+		ratings:    make(map[uint]map[string]float64),
+		userItems:  make(map[string]map[uint]float64),
+		neighbors:  make(map[uint]map[uint]itemNeighbor),
+	}
+}
+
+// Rebuild recomputes the whole model from the review table
+func (s *ItemSimilarityService) Rebuild() error {
+	rows, err := s.ratingRepo.GetAllRatings()
+	if err != nil {
+		return err
+	}
+
+	ratings := make(map[uint]map[string]float64)
+	userItems := make(map[string]map[uint]float64)
+	for _, row := range rows {
+		// Rows come oldest first, so a reviewer's latest rating of a perfume wins
+		setRating(ratings, userItems, row.PerfumeID, row.Reviewer, row.Rating)
+	}
+
+	neighbors := make(map[uint]map[uint]itemNeighbor, len(ratings))
+	for perfumeID := range ratings {
+		neighbors[perfumeID] = computeNeighbors(perfumeID, ratings, userItems)
+	}
+
+	s.mu.Lock()
+	s.ratings = ratings
+	s.userItems = userItems
+	s.neighbors = neighbors
+	s.builtAt = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
+
+// AddReview folds a new review into the model. Only pairs of perfumes rated by
+// the same reviewer can change, so only their rows are recomputed.
+func (s *ItemSimilarityService) AddReview(review *models.EnhancedReviewGORM) {
+	if s == nil || review == nil || review.OverallRating < 1 || review.OverallRating > 5 {
+		return
+	}
+	user := reviewerKey(review.UserEmail, review.UserName)
+	if user == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	setRating(s.ratings, s.userItems, uint(review.PerfumeID), user, review.OverallRating)
+	for perfumeID := range s.userItems[user] {
+		for other := range s.neighbors[perfumeID] {
+			delete(s.neighbors[other], perfumeID)
+		}
+		row := computeNeighbors(perfumeID, s.ratings, s.userItems)
+		s.neighbors[perfumeID] = row
+		for other, neighbor := range row {
+			if s.neighbors[other] == nil {
+				s.neighbors[other] = make(map[uint]itemNeighbor)
+			}
+			s.neighbors[other][perfumeID] = neighbor
+		}
+	}
+}
+
+// Start rebuilds the model on a fixed interval until the returned stop function is called
+func (s *ItemSimilarityService) Start(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Rebuild(); err != nil {
+					log.Printf("Failed to rebuild item similarity model: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// AlsoLoved returns the perfumes most similar to perfumeID by co-rating, topped
+// up with content-similar perfumes when the community signal is too thin
+func (s *ItemSimilarityService) AlsoLoved(perfumeID uint, limit int) (*models.AlsoLovedResponse, error) {
+	if limit <= 0 {
+		limit = DefaultAlsoLovedLimit
+	}
+	if limit > MaxAlsoLovedLimit {
+		limit = MaxAlsoLovedLimit
+	}
+
+	catalog, err := s.catalog.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	target, ok := catalog.Get(perfumeID)
+	if !ok {
+		return nil, ErrPerfumeNotFound
+	}
+
+	s.mu.RLock()
+	builtAt := s.builtAt
+	var community []models.SimilarPerfume
+	for otherID, neighbor := range s.neighbors[perfumeID] {
+		other, ok := catalog.Get(otherID)
+		if !ok || neighbor.similarity <= 0 {
+			continue
+		}
+		community = append(community, models.SimilarPerfume{
+			Perfume:    *other,
+			Similarity: neighbor.similarity,
+			Source:     models.SimilaritySourceCommunity,
+			CoRaters:   neighbor.coRaters,
+		})
+	}
+	s.mu.RUnlock()
+
+	sortSimilar(community)
+	results := community
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	// Cold start: fill the remaining slots from content similarity
+	if len(results) < limit {
+		included := map[uint]bool{perfumeID: true}
+		for _, result := range results {
+			included[result.Perfume.ID] = true
+		}
+		targetFeatures := catalog.Features(target)
+		var content []models.SimilarPerfume
+		for i := range catalog.Perfumes {
+			other := &catalog.Perfumes[i]
+			if included[other.ID] {
+				continue
+			}
+			if similarity := contentSimilarity(targetFeatures, catalog.Features(other)); similarity > 0 {
+				content = append(content, models.SimilarPerfume{
+					Perfume:    *other,
+					Similarity: similarity,
+					Source:     models.SimilaritySourceContent,
+				})
+			}
+		}
+		sortSimilar(content)
+		if missing := limit - len(results); len(content) > missing {
+			content = content[:missing]
+		}
+		results = append(results, content...)
+	}
+
+	if results == nil {
+		results = []models.SimilarPerfume{}
+	}
+	return &models.AlsoLovedResponse{PerfumeID: perfumeID, Results: results, ModelBuiltAt: builtAt}, nil
+}
+
+// Alternatives returns perfumes cheaper than the reference, and at most
+// maxPrice when it is positive, ranked by content similarity to it. Only
+// perfumes at least minAlternativeSimilarity alike are returned.
+func (s *ItemSimilarityService) Alternatives(perfumeID uint, maxPrice float64, limit int) (*models.AlternativesResponse, error) {
+	if limit <= 0 {
+		limit = DefaultAlternativesLimit
+	}
+	limit = min(limit, MaxAlternativesLimit)
+
+	catalog, err := s.catalog.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	target, ok := catalog.Get(perfumeID)
+	if !ok {
+		return nil, ErrPerfumeNotFound
+	}
+
+	targetFeatures := catalog.Features(target)
+	results := []models.AlternativePerfume{}
+	for i := range catalog.Perfumes {
+		other := &catalog.Perfumes[i]
+		if other.ID == perfumeID || other.Price <= 0 || other.Price >= target.Price || (maxPrice > 0 && other.Price > maxPrice) {
+			continue
+		}
+		similarity := contentSimilarity(targetFeatures, catalog.Features(other))
+		if similarity < minAlternativeSimilarity {
+			continue
+		}
+
+		// Both sides count distinct note keys: a note listed in several
+		// pyramid layers is one note
+		shared := sharedNotes(target, other)
+		sharedPercent := 0.0
+		if len(targetFeatures.Notes) > 0 {
+			sharedPercent = 100 * float64(len(shared)) / float64(len(targetFeatures.Notes))
+		}
+		if shared == nil {
+			shared = []string{}
+		}
+		saving := target.Price - other.Price
+		results = append(results, models.AlternativePerfume{
+			Perfume:           *other,
+			Similarity:        similarity,
+			SharedNotes:       shared,
+			SharedNotePercent: math.Round(sharedPercent),
+			PriceSaving:       saving,
+			SavingPercent:     math.Round(100 * saving / target.Price),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Similarity != results[j].Similarity {
+			return results[i].Similarity > results[j].Similarity
+		}
+		if results[i].Perfume.Price != results[j].Perfume.Price {
+			return results[i].Perfume.Price < results[j].Perfume.Price
+		}
+		return results[i].Perfume.ID < results[j].Perfume.ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return &models.AlternativesResponse{
+		PerfumeID:      perfumeID,
+		ReferencePrice: target.Price,
+		MaxPrice:       maxPrice,
+		MinSimilarity:  minAlternativeSimilarity,
+		Results:        results,
+	}, nil
+}
+
+// Similarity returns how related two perfumes are (0-1), using co-ratings when
+// enough reviewers rated both and content similarity otherwise
+func (s *ItemSimilarityService) Similarity(a, b *models.Perfume) (float64, string) {
+	// Without a catalog the features are computed from the perfumes themselves
+	catalog, _ := s.catalog.Snapshot()
+	return s.similarity(catalog, a, b)
+}
+
+func (s *ItemSimilarityService) similarity(catalog *CatalogSnapshot, a, b *models.Perfume) (float64, string) {
+	s.mu.RLock()
+	neighbor, ok := s.neighbors[a.ID][b.ID]
+	s.mu.RUnlock()
+	if ok {
+		return math.Max(neighbor.similarity, 0), models.SimilaritySourceCommunity
+	}
+	return contentSimilarity(catalog.Features(a), catalog.Features(b)), models.SimilaritySourceContent
+}
+
+// RatingSummary returns how many reviewers rated the perfume, their mean
+// rating and its standard deviation, from the last rebuild
+func (s *ItemSimilarityService) RatingSummary(perfumeID uint) (count int, mean, stddev float64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ratings := s.ratings[perfumeID]
+	if len(ratings) == 0 {
+		return 0, 0, 0
+	}
+	sum, squares := 0.0, 0.0
+	for _, centred := range ratings {
+		sum += centred
+		squares += centred * centred
+	}
+	count = len(ratings)
+	average := sum / float64(count)
+	variance := math.Max(squares/float64(count)-average*average, 0)
+	return count, neutralRating + average, math.Sqrt(variance)
+}
+
+// CommunityScore is the highest similarity between a perfume and any of the liked perfumes
+func (s *ItemSimilarityService) CommunityScore(perfume *models.Perfume, likedIDs []uint) (float64, string) {
+	catalog, err := s.catalog.Snapshot()
+	if err != nil {
+		log.Printf("Failed to load catalog for community scoring: %v", err)
+		return 0, ""
+	}
+
+	best, source := 0.0, ""
+	for _, id := range likedIDs {
+		liked, ok := catalog.Get(id)
+		if !ok || id == perfume.ID {
+			continue
+		}
+		if similarity, from := s.similarity(catalog, liked, perfume); similarity > best {
+			best, source = similarity, from
+		}
+	}
+	return best, source
+}
+
+func setRating(ratings map[uint]map[string]float64, userItems map[string]map[uint]float64, perfumeID uint, user string, rating int) {
+	centred := float64(rating) - neutralRating
+	if ratings[perfumeID] == nil {
+		ratings[perfumeID] = make(map[string]float64)
+	}
+	ratings[perfumeID][user] = centred
+	if userItems[user] == nil {
+		userItems[user] = make(map[uint]float64)
+	}
+	userItems[user][perfumeID] = centred
+}
+
+// computeNeighbors returns the cosine similarity of the centred rating vectors of
+// perfumeID and every perfume that shares at least minCoRaters reviewers with it
+func computeNeighbors(perfumeID uint, ratings map[uint]map[string]float64, userItems map[string]map[uint]float64) map[uint]itemNeighbor {
+	type accumulator struct {
+		dot, normA, normB float64
+		count             int
+	}
+
+	sums := make(map[uint]*accumulator)
+	for user, a := range ratings[perfumeID] {
+		for otherID, b := range userItems[user] {
+			if otherID == perfumeID {
+				continue
+			}
+			acc, ok := sums[otherID]
+			if !ok {
+				acc = &accumulator{}
+				sums[otherID] = acc
+			}
+			acc.dot += a * b
+			acc.normA += a * a
+			acc.normB += b * b
+			acc.count++
+		}
+	}
+
+	row := make(map[uint]itemNeighbor)
+	for otherID, acc := range sums {
+		if acc.count < minCoRaters || acc.normA == 0 || acc.normB == 0 {
+			continue
+		}
+		cosine := acc.dot / math.Sqrt(acc.normA*acc.normB)
+		shrink := float64(acc.count) / (float64(acc.count) + similarityShrinkage)
+		row[otherID] = itemNeighbor{similarity: cosine * shrink, coRaters: acc.count}
+	}
+	return row
+}
+
+// contentSimilarity blends the weighted Jaccard similarity of the accords with
+// the Jaccard similarity of the note names
+func contentSimilarity(a, b *PerfumeFeatures) float64 {
+	var intersection, union float64
+	for id, weightA := range a.Accords {
+		weightB := b.Accords[id]
+		intersection += math.Min(weightA, weightB)
+		union += math.Max(weightA, weightB)
+	}
+	for id, weightB := range b.Accords {
+		if _, ok := a.Accords[id]; !ok {
+			union += weightB
+		}
+	}
+	accordScore := 0.0
+	if union > 0 {
+		accordScore = intersection / union
+	}
+
+	shared := 0
+	for key := range b.Notes {
+		if a.Notes[key] {
+			shared++
+		}
+	}
+	noteScore := 0.0
+	if total := len(a.Notes) + len(b.Notes) - shared; total > 0 {
+		noteScore = float64(shared) / float64(total)
+	}
+
+	return 0.7*accordScore + 0.3*noteScore
+}
+
+func sortSimilar(perfumes []models.SimilarPerfume) {
+	sort.Slice(perfumes, func(i, j int) bool {
+		if perfumes[i].Similarity != perfumes[j].Similarity {
+			return perfumes[i].Similarity > perfumes[j].Similarity
+		}
+		return perfumes[i].Perfume.ID < perfumes[j].Perfume.ID
+	})
+}
+
+func reviewerKey(email, name string) string {
+	if email = strings.TrimSpace(email); email != "" {
+		return strings.ToLower(email)
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
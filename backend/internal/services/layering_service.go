@@ -0,0 +1,477 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"perfume-website/internal/models"
+)
+
+// ErrInvalidLayeringRequest is returned when a layering request names neither a
+// perfume nor a known vibe
+var ErrInvalidLayeringRequest = errors.New("invalid layering request")
+
+const (
+	DefaultLayeringLimit = 6
+	MaxLayeringLimit     = 24
+
+	// layeringVibeAnchors is how many of the best perfumes for a vibe are paired
+	// with the rest of the catalog
+	layeringVibeAnchors = 8
+	// layeringVibeWeight is the share of a vibe pair's score that comes from how
+	// well the two perfumes suit the vibe, rather than from how well they layer
+	layeringVibeWeight = 0.3
+	// layeringClashThreshold is the clash strength above which a pairing is
+	// explained as clashing
+	layeringClashThreshold = 0.25
+)
+
+// layeringWeights balance the components of a pairing score
+var layeringWeights = map[string]float64{
+	"notes":   0.5,
+	"harmony": 0.3,
+	"sillage": 0.2,
+}
+
+// layeringComponents orders the pairing score components, so scores are
+// summed the same way every time
+var layeringComponents = []string{"notes", "harmony", "sillage"}
+
+// noteFamilies groups note names into olfactive families. Terms match note
+// names as whole words, and a note can belong to several families.
+var noteFamilies = map[string][]string{
+	"citrus":   {"citrus", "bergamot", "lemon", "lime", "grapefruit", "mandarin", "tangerine", "yuzu", "orange", "neroli"},
+	"fresh":    {"fresh", "mint", "ozonic", "aldehydes"},
+	"aquatic":  {"aquatic", "marine", "sea", "water", "salt", "calone"},
+	"green":    {"green", "galbanum", "fig leaf", "tea", "grass", "basil"},
+	"aromatic": {"lavender", "rosemary", "sage", "thyme", "geranium", "artemisia"},
+	"fruity":   {"apple", "pear", "peach", "plum", "berry", "blackcurrant", "raspberry", "cherry", "pineapple", "lychee"},
+	"floral":   {"rose", "jasmine", "iris", "violet", "tuberose", "lily", "peony", "ylang-ylang", "orange blossom", "magnolia", "freesia", "orchid"},
+	"spicy":    {"pepper", "pink pepper", "cardamom", "cinnamon", "clove", "saffron", "ginger", "nutmeg"},
+	"gourmand": {"vanilla", "tonka", "tonka bean", "caramel", "chocolate", "cacao", "coffee", "honey", "praline", "almond"},
+	"amber":    {"amber", "labdanum", "benzoin", "incense", "myrrh", "frankincense", "resin", "olibanum"},
+	"woody":    {"wood", "woods", "cedar", "cedarwood", "sandalwood", "vetiver", "patchouli", "guaiac", "cashmeran", "birch"},
+	"oud":      {"oud", "agarwood"},
+	"musk":     {"musk", "white musk", "ambrette", "ambroxan"},
+	"leather":  {"leather", "suede", "tobacco"},
+}
+
+// complementaryFamilies lists, for a top-note family, the base-note families
+// that anchor it when one perfume is worn over another
+var complementaryFamilies = map[string][]string{
+	"citrus":   {"woody", "musk", "amber", "oud"},
+	"fresh":    {"woody", "musk"},
+	"aquatic":  {"woody", "musk"},
+	"green":    {"woody", "musk", "amber"},
+	"aromatic": {"woody", "amber", "leather"},
+	"fruity":   {"gourmand", "musk", "woody"},
+	"floral":   {"musk", "woody", "gourmand", "amber", "oud"},
+	"spicy":    {"amber", "gourmand", "woody", "leather", "oud"},
+}
+
+// clashingFamilies are families that tend to fight rather than blend
+var clashingFamilies = [][2]string{
+	{"aquatic", "gourmand"},
+	{"aquatic", "oud"},
+	{"aquatic", "leather"},
+	{"fresh", "oud"},
+	{"fruity", "leather"},
+	{"fruity", "oud"},
+	{"green", "gourmand"},
+}
+
+// familyNotes is how strongly a family shows in one tier of a perfume, and the notes behind it
+type familyNotes struct {
+	weight float64
+	notes  []string
+}
+
+// layeringProfile is a perfume broken down for pairing
+type layeringProfile struct {
+	perfume  *models.Perfume
+	top      map[string]*familyNotes
+	base     map[string]*familyNotes
+	families map[string]float64 // accords and note families, 0-1
+	sillage  int                // 0 when unknown
+}
+
+func newLayeringProfile(perfume *models.Perfume) *layeringProfile {
+	profile := &layeringProfile{
+		perfume:  perfume,
+		top:      make(map[string]*familyNotes),
+		base:     make(map[string]*familyNotes),
+		families: make(map[string]float64),
+		sillage:  sillageLevel(perfume.Sillage),
+	}
+
+	strengths := perfume.AccordStrengths()
+	for _, tag := range perfume.AromaTags {
+		strength := float64(strengths[tag.ID]) / models.MaxAccordStrength
+		for _, key := range []string{noteKey(tag.Slug), noteKey(tag.Name)} {
+			profile.families[key] = math.Max(profile.families[key], strength)
+		}
+	}
+
+	for _, note := range perfume.Notes {
+		weight := noteWeight(note)
+		for family, terms := range noteFamilies {
+			if !noteMatchesAny(note.NoteName, terms) {
+				continue
+			}
+			profile.families[family] = math.Max(profile.families[family], weight)
+			switch note.Type {
+			case models.NoteTypeTop:
+				addFamilyNote(profile.top, family, note.NoteName, weight)
+			case models.NoteTypeBase:
+				addFamilyNote(profile.base, family, note.NoteName, weight)
+			}
+		}
+	}
+	return profile
+}
+
+func noteMatchesAny(noteName string, terms []string) bool {
+	for _, term := range terms {
+		if noteMatches(noteName, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func addFamilyNote(tier map[string]*familyNotes, family, noteName string, weight float64) {
+	entry, ok := tier[family]
+	if !ok {
+		entry = &familyNotes{}
+		tier[family] = entry
+	}
+	entry.weight = math.Max(entry.weight, weight)
+	entry.notes = append(entry.notes, noteName)
+}
+
+// noteComplement scores how well the lower perfume's base anchors the upper
+// perfume's top notes (0-1), weighting each top family by its prominence. It
+// returns the top and base notes that complement each other.
+func noteComplement(upper, lower *layeringProfile) (float64, []string, []string) {
+	total, matched := 0.0, 0.0
+	var topNotes, baseNotes []string
+	for family, top := range upper.top {
+		total += top.weight
+		best := 0.0
+		var anchor *familyNotes
+		for _, complement := range complementaryFamilies[family] {
+			if base, ok := lower.base[complement]; ok && base.weight > best {
+				best, anchor = base.weight, base
+			}
+		}
+		if anchor == nil {
+			continue
+		}
+		matched += top.weight * best
+		topNotes = appendUnique(topNotes, top.notes...)
+		baseNotes = appendUnique(baseNotes, anchor.notes...)
+	}
+	if total == 0 {
+		return 0, nil, nil
+	}
+	sort.Strings(topNotes)
+	sort.Strings(baseNotes)
+	return matched / total, topNotes, baseNotes
+}
+
+func appendUnique(values []string, more ...string) []string {
+	for _, value := range more {
+		if !containsFold(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// familyClash returns the strongest clash between the two perfumes' families
+// (0-1) and the families involved
+func familyClash(a, b *layeringProfile) (float64, [2]string) {
+	worst, pair := 0.0, [2]string{}
+	for _, clash := range clashingFamilies {
+		if strength := a.families[clash[0]] * b.families[clash[1]]; strength > worst {
+			worst, pair = strength, clash
+		}
+		if strength := a.families[clash[1]] * b.families[clash[0]]; strength > worst {
+			worst, pair = strength, [2]string{clash[1], clash[0]}
+		}
+	}
+	return worst, pair
+}
+
+// sillageBalance scores whether two perfumes project at compatible strengths
+// (0-1): close levels blend, far apart ones drown each other, and two strong
+// ones together overwhelm
+func sillageBalance(upper, lower *layeringProfile) (float64, models.Explanation) {
+	if upper.sillage == 0 || lower.sillage == 0 {
+		return 0.5, models.Explanation{}
+	}
+	params := map[string]any{"upper_sillage": upper.perfume.Sillage, "lower_sillage": lower.perfume.Sillage}
+	if upper.sillage >= 3 && lower.sillage >= 3 {
+		return 0.3, explain("layering.overpowering", params)
+	}
+	switch diff := upper.sillage - lower.sillage; {
+	case diff >= -1 && diff <= 1:
+		return 1, explain("layering.balanced", params)
+	case diff >= -2 && diff <= 2:
+		return 0.5, explain("layering.uneven", params)
+	default:
+		return 0, explain("layering.uneven", params)
+	}
+}
+
+// LayeringService suggests perfumes that are worn well together
+type LayeringService struct {
+	catalog *CatalogService
+}
+
+func NewLayeringService(catalog *CatalogService) *LayeringService {
+	return &LayeringService{catalog: catalog}
+}
+
+// Recommend returns the best partners for the requested perfume, or the best
+// pairs for the requested vibe
+func (s *LayeringService) Recommend(req models.LayeringRequest) (*models.LayeringResponse, error) {
+	vibe := strings.ToLower(strings.TrimSpace(req.Vibe))
+	if (req.PerfumeID == 0) == (vibe == "") {
+		return nil, fmt.Errorf("%w: give either perfume_id or vibe", ErrInvalidLayeringRequest)
+	}
+	var vibeProfile *wearProfile
+	if vibe != "" {
+		profile, ok := impressionProfiles[vibe]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown vibe '%s'", ErrInvalidLayeringRequest, req.Vibe)
+		}
+		vibeProfile = &profile
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultLayeringLimit
+	}
+	limit = min(limit, MaxLayeringLimit)
+
+	catalog, err := s.catalog.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]*layeringProfile, len(catalog.Perfumes))
+	for i := range catalog.Perfumes {
+		profiles[i] = newLayeringProfile(&catalog.Perfumes[i])
+	}
+
+	response := &models.LayeringResponse{PerfumeID: req.PerfumeID, Vibe: vibe}
+	if vibeProfile != nil {
+		response.Pairings = pairForVibe(profiles, vibe, vibeProfile)
+	} else {
+		anchor := findLayeringProfile(profiles, req.PerfumeID)
+		if anchor == nil {
+			return nil, ErrPerfumeNotFound
+		}
+		for _, partner := range profiles {
+			if partner.perfume.ID != anchor.perfume.ID {
+				response.Pairings = append(response.Pairings, scorePairing(anchor, partner))
+			}
+		}
+	}
+
+	sort.SliceStable(response.Pairings, func(i, j int) bool {
+		a, b := response.Pairings[i], response.Pairings[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Perfume.ID != b.Perfume.ID {
+			return a.Perfume.ID < b.Perfume.ID
+		}
+		return a.Partner.ID < b.Partner.ID
+	})
+	if len(response.Pairings) > limit {
+		response.Pairings = response.Pairings[:limit]
+	}
+	if response.Pairings == nil {
+		response.Pairings = []models.LayeringPairing{}
+	}
+	return response, nil
+}
+
+func findLayeringProfile(profiles []*layeringProfile, perfumeID uint) *layeringProfile {
+	for _, profile := range profiles {
+		if profile.perfume.ID == perfumeID {
+			return profile
+		}
+	}
+	return nil
+}
+
+// pairForVibe pairs the perfumes that best suit the vibe with the rest of the
+// catalog, scoring each pair on layering and on how well both suit the vibe
+func pairForVibe(profiles []*layeringProfile, vibe string, vibeProfile *wearProfile) []models.LayeringPairing {
+	fits := make(map[uint]float64, len(profiles))
+	var anchors []*layeringProfile
+	for _, profile := range profiles {
+		fit, _ := calculateWearMatch(*profile.perfume, vibeProfile, "impression", vibe)
+		fits[profile.perfume.ID] = fit
+		if fit > 0 {
+			anchors = append(anchors, profile)
+		}
+	}
+	sort.SliceStable(anchors, func(i, j int) bool {
+		return fits[anchors[i].perfume.ID] > fits[anchors[j].perfume.ID]
+	})
+	if len(anchors) > layeringVibeAnchors {
+		anchors = anchors[:layeringVibeAnchors]
+	}
+
+	type pairKey struct{ low, high uint }
+	seen := make(map[pairKey]bool)
+	var pairings []models.LayeringPairing
+	for _, anchor := range anchors {
+		for _, partner := range profiles {
+			a, b := anchor.perfume.ID, partner.perfume.ID
+			key := pairKey{min(a, b), max(a, b)}
+			if a == b || seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			pairing := scorePairing(anchor, partner)
+			fit := (fits[a] + fits[b]) / 2
+			score := layeringVibeWeight * fit
+			for _, component := range layeringComponents {
+				pairing.ScoreBreakdown[component] *= 1 - layeringVibeWeight
+				score += pairing.ScoreBreakdown[component]
+			}
+			pairing.ScoreBreakdown["vibe"] = layeringVibeWeight * fit
+			pairing.Score = roundTo2(score)
+			pairing.Explanations = append(pairing.Explanations, explain("layering.vibe", map[string]any{"vibe": vibe, "fit": roundTo2(fit)}))
+			pairings = append(pairings, pairing)
+		}
+	}
+	return pairings
+}
+
+// scorePairing scores wearing the partner with the anchor. The perfume whose
+// base best anchors the other's top notes is applied first.
+func scorePairing(anchor, partner *layeringProfile) models.LayeringPairing {
+	upper, lower := partner, anchor
+	complement, topNotes, baseNotes := noteComplement(partner, anchor)
+	if reverse, reverseTop, reverseBase := noteComplement(anchor, partner); reverse > complement ||
+		(reverse == complement && partner.sillage > anchor.sillage) {
+		upper, lower = anchor, partner
+		complement, topNotes, baseNotes = reverse, reverseTop, reverseBase
+	}
+
+	var explanations []models.Explanation
+	if complement > 0 {
+		explanations = append(explanations, explain("layering.complement", map[string]any{
+			"first":      lower.perfume.Name,
+			"second":     upper.perfume.Name,
+			"base_notes": baseNotes,
+			"top_notes":  topNotes,
+		}))
+	}
+	notes := complement
+	if shared := sharedNotes(anchor.perfume, partner.perfume); len(shared) > 0 {
+		// A shared note bridges the two, but too many just make them redundant
+		notes = math.Min(1, notes+0.1*float64(min(len(shared), 2)))
+		explanations = append(explanations, explain("layering.bridge", map[string]any{"notes": shared}))
+	}
+
+	harmony := 0.5
+	if len(anchor.families) > 0 && len(partner.families) > 0 {
+		clash, families := familyClash(anchor, partner)
+		harmony = 1 - clash
+		if clash > layeringClashThreshold {
+			explanations = append(explanations, explain("layering.clash", map[string]any{"family": families[0], "other_family": families[1]}))
+		} else {
+			explanations = append(explanations, explain("layering.harmony", nil))
+		}
+	}
+
+	sillage, sillageExplanation := sillageBalance(upper, lower)
+	if sillageExplanation.Code != "" {
+		explanations = append(explanations, sillageExplanation)
+	}
+
+	breakdown := map[string]float64{
+		"notes":   layeringWeights["notes"] * notes,
+		"harmony": layeringWeights["harmony"] * harmony,
+		"sillage": layeringWeights["sillage"] * sillage,
+	}
+	score := 0.0
+	for _, component := range layeringComponents {
+		score += breakdown[component]
+	}
+
+	return models.LayeringPairing{
+		Perfume:        *anchor.perfume,
+		Partner:        *partner.perfume,
+		ApplyFirstID:   lower.perfume.ID,
+		Score:          roundTo2(score),
+		ScoreBreakdown: breakdown,
+		Pyramid:        layeredPyramid(lower.perfume, upper.perfume),
+		Explanations:   explanations,
+	}
+}
+
+// layeredPyramid merges both pyramids, most intense notes first. A note in both
+// perfumes appears once with the higher intensity.
+func layeredPyramid(perfumes ...*models.Perfume) models.LayeredPyramid {
+	tiers := map[models.NoteType][]models.LayeredNote{}
+	index := map[models.NoteType]map[string]int{}
+	for _, perfume := range perfumes {
+		for _, note := range perfume.Notes {
+			tier := note.Type
+			if tier != models.NoteTypeTop && tier != models.NoteTypeBase {
+				tier = models.NoteTypeMiddle
+			}
+			if index[tier] == nil {
+				index[tier] = make(map[string]int)
+			}
+			key := noteKey(note.NoteName)
+			if i, ok := index[tier][key]; ok {
+				layered := &tiers[tier][i]
+				layered.Intensity = max(layered.Intensity, note.Intensity)
+				if !slices.Contains(layered.PerfumeIDs, perfume.ID) {
+					layered.PerfumeIDs = append(layered.PerfumeIDs, perfume.ID)
+				}
+				continue
+			}
+			index[tier][key] = len(tiers[tier])
+			tiers[tier] = append(tiers[tier], models.LayeredNote{
+				NoteName:   note.NoteName,
+				Intensity:  note.Intensity,
+				PerfumeIDs: []uint{perfume.ID},
+			})
+		}
+	}
+
+	for _, notes := range tiers {
+		sort.SliceStable(notes, func(i, j int) bool {
+			if notes[i].Intensity != notes[j].Intensity {
+				return notes[i].Intensity > notes[j].Intensity
+			}
+			return notes[i].NoteName < notes[j].NoteName
+		})
+	}
+	return models.LayeredPyramid{
+		Top:   emptyIfNil(tiers[models.NoteTypeTop]),
+		Heart: emptyIfNil(tiers[models.NoteTypeMiddle]),
+		Base:  emptyIfNil(tiers[models.NoteTypeBase]),
+	}
+}
+
+func emptyIfNil(notes []models.LayeredNote) []models.LayeredNote {
+	if notes == nil {
+		return []models.LayeredNote{}
+	}
+	return notes
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"perfume-website/internal/models"
+)
+
+const (
+	NotePreferencePreferred = "preferred"
+	NotePreferenceAvoided   = "avoided"
+
+	// defaultNoteIntensity is assumed for notes imported without an intensity
+	defaultNoteIntensity = 5
+	maxNoteIntensity     = 10
+)
+
+// notePositionWeights reflect how long a note stays on the skin: top notes fade
+// within minutes, while heart and base notes define the fragrance for hours.
+var notePositionWeights = map[models.NoteType]float64{
+	models.NoteTypeTop:    0.6,
+	models.NoteTypeMiddle: 0.9,
+	models.NoteTypeBase:   1.0,
+}
+
+// normalizeNoteTerms lower-cases and trims note names, dropping blanks and duplicates
+func normalizeNoteTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	var normalized []string
+	for _, term := range terms {
+		term = noteKey(term)
+		if term != "" && !seen[term] {
+			seen[term] = true
+			normalized = append(normalized, term)
+		}
+	}
+	return normalized
+}
+
+// noteMatches reports whether a note name contains the term as whole words,
+// so "rose" matches "Bulgarian Rose" but "oud" does not match "Cloud".
+func noteMatches(noteName, term string) bool {
+	name := noteKey(noteName)
+	return name == term ||
+		strings.HasPrefix(name, term+" ") ||
+		strings.HasSuffix(name, " "+term) ||
+		strings.Contains(name, " "+term+" ")
+}
+
+// noteWeight is the prominence of a note (0-1) from its intensity and pyramid position
+func noteWeight(note models.Note) float64 {
+	intensity := note.Intensity
+	if intensity <= 0 {
+		intensity = defaultNoteIntensity
+	}
+	intensity = min(intensity, maxNoteIntensity)
+
+	position, ok := notePositionWeights[note.Type]
+	if !ok {
+		position = notePositionWeights[models.NoteTypeMiddle]
+	}
+	return position * float64(intensity) / maxNoteIntensity
+}
+
+// containsAnyNote reports whether the perfume has a note matching one of the terms
+func containsAnyNote(perfume models.Perfume, terms []string) bool {
+	for _, note := range perfume.Notes {
+		for _, term := range terms {
+			if noteMatches(note.NoteName, term) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sharedNotes returns the note names both perfumes contain
+func sharedNotes(a, b *models.Perfume) []string {
+	names := make(map[string]bool, len(a.Notes))
+	for _, note := range a.Notes {
+		names[noteKey(note.NoteName)] = true
+	}
+	var shared []string
+	for _, note := range b.Notes {
+		if names[noteKey(note.NoteName)] {
+			shared = appendUnique(shared, note.NoteName)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// calculateNoteMatch scores a perfume against preferred and avoided notes.
+// Preferred notes count by how prominent their best match is, averaged over the
+// preferred terms; the most prominent avoided note is subtracted as a penalty.
+func calculateNoteMatch(perfume models.Perfume, preferred, avoided []string) (float64, []models.MatchedNote) {
+	var matched []models.MatchedNote
+	best := make(map[string]float64, len(preferred))
+	penalty := 0.0
+
+	for _, note := range perfume.Notes {
+		weight := noteWeight(note)
+		matchedAs := ""
+		for _, term := range preferred {
+			if noteMatches(note.NoteName, term) {
+				best[term] = math.Max(best[term], weight)
+				matchedAs = NotePreferencePreferred
+			}
+		}
+		for _, term := range avoided {
+			if noteMatches(note.NoteName, term) {
+				penalty = math.Max(penalty, weight)
+				matchedAs = NotePreferenceAvoided
+			}
+		}
+		if matchedAs != "" {
+			matched = append(matched, models.MatchedNote{
+				NoteName:   note.NoteName,
+				Type:       note.Type,
+				Intensity:  note.Intensity,
+				Preference: matchedAs,
+				Weight:     weight,
+			})
+		}
+	}
+
+	score := 1.0
+	if len(preferred) > 0 {
+		score = 0.0
+		for _, weight := range best {
+			score += weight
+		}
+		score /= float64(len(preferred))
+	}
+
+	return math.Max(score-penalty, 0), matched
+}
+
+// describeAvoidedNotes warns about the avoided notes a perfume contains
+func describeAvoidedNotes(matched []models.MatchedNote) []string {
+	var avoided []string
+	for _, note := range matched {
+		if note.Preference == NotePreferenceAvoided {
+			avoided = append(avoided, note.NoteName)
+		}
+	}
+	if len(avoided) == 0 {
+		return nil
+	}
+	return []string{"Contains notes you prefer to avoid: " + strings.Join(avoided, ", ")}
+}
+
+// explainMatchedNotes groups matched notes into one explanation per preference
+func explainMatchedNotes(matched []models.MatchedNote) []models.Explanation {
+	var preferred, avoided []string
+	for _, note := range matched {
+		if note.Preference == NotePreferenceAvoided {
+			avoided = append(avoided, note.NoteName)
+		} else {
+			preferred = append(preferred, note.NoteName)
+		}
+	}
+
+	var explanations []models.Explanation
+	if len(preferred) > 0 {
+		explanations = append(explanations, explain("notes.preferred", map[string]any{"notes": preferred}))
+	}
+	if len(avoided) > 0 {
+		explanations = append(explanations, explain("notes.avoided", map[string]any{"notes": avoided}))
+	}
+	return explanations
+}
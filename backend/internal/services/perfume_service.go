@@ -1,8 +1,21 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"perfume-website/internal/models"
 	"perfume-website/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPerfumeNotFound is returned when a perfume ID does not exist
+	ErrPerfumeNotFound = errors.New("perfume not found")
+	// ErrInvalidAccords is returned when submitted accord strengths fail validation
+	ErrInvalidAccords = errors.New("invalid accords")
 )
 
 type PerfumeService interface {
@@ -13,25 +26,58 @@ type PerfumeService interface {
 	DeletePerfume(id uint) error
 	GetPerfumeWithRelations(id uint) (*models.Perfume, error)
 	GetAllPerfumesWithRelations() ([]models.Perfume, error)
-	GetPerfumesWithPagination(page, limit int, search, brand, aroma string) ([]models.Perfume, int64, error)
-	RecommendPerfumes(aromaSlugs []string) (*models.RecommendationResponse, error)
+	GetPerfumesWithPagination(page, limit int, search, brand, aroma string, excludeIngredients []string) ([]models.Perfume, int64, int64, error)
+	RecommendPerfumes(req models.RecommendationRequest) (*models.RecommendationResponse, error)
+	RegisterScorer(scorer Scorer)
+	ScoringStrategies() []string
+	UpdatePerfumeAccords(id uint, accords []models.AccordInput) (*models.Perfume, error)
 //GetCategories() ([]map[string]interface{}, error)
 }
 
 type perfumeService struct {
 	perfumeRepo repositories.PerfumeRepository
 	aromaRepo   repositories.AromaRepository
+	notifier    *CatalogNotifier
+	catalog     *CatalogService
+	scorers     *ScorerRegistry
 }
 
-func NewPerfumeService(perfumeRepo repositories.PerfumeRepository, aromaRepo repositories.AromaRepository) PerfumeService {
+func NewPerfumeService(perfumeRepo repositories.PerfumeRepository, aromaRepo repositories.AromaRepository, notifier *CatalogNotifier, catalog *CatalogService) PerfumeService {
 	return &perfumeService{
 		perfumeRepo: perfumeRepo,
 		aromaRepo:   aromaRepo,
+		notifier:    notifier,
+		catalog:     catalog,
+		scorers:     NewScorerRegistry(),
 	}
 }
 
+// RegisterScorer makes an additional scoring strategy selectable by name
+func (s *perfumeService) RegisterScorer(scorer Scorer) {
+	s.scorers.Register(scorer)
+}
+
+// ScoringStrategies lists the strategies /api/recommend accepts
+func (s *perfumeService) ScoringStrategies() []string {
+	return s.scorers.Names()
+}
+
 func (s *perfumeService) CreatePerfume(perfume *models.Perfume) error {
-	return s.perfumeRepo.Create(perfume)
+	accords, err := s.takeAccords(perfume)
+	if err != nil {
+		return err
+	}
+	if err := s.perfumeRepo.Create(perfume); err != nil {
+		return err
+	}
+	if accords != nil {
+		if err := s.perfumeRepo.ReplaceAccords(perfume.ID, accords); err != nil {
+			return err
+		}
+		perfume.Accords = accords
+	}
+	s.notifier.Notify()
+	return nil
 }
 
 func (s *perfumeService) GetPerfume(id uint) (*models.Perfume, error) {
@@ -43,11 +89,29 @@ func (s *perfumeService) GetAllPerfumes() ([]models.Perfume, error) {
 }
 
 func (s *perfumeService) UpdatePerfume(perfume *models.Perfume) error {
-	return s.perfumeRepo.Update(perfume)
+	accords, err := s.takeAccords(perfume)
+	if err != nil {
+		return err
+	}
+	if err := s.perfumeRepo.Update(perfume); err != nil {
+		return err
+	}
+	if accords != nil {
+		if err := s.perfumeRepo.ReplaceAccords(perfume.ID, accords); err != nil {
+			return err
+		}
+		perfume.Accords = accords
+	}
+	s.notifier.Notify()
+	return nil
 }
 
 func (s *perfumeService) DeletePerfume(id uint) error {
-	return s.perfumeRepo.Delete(id)
+	if err := s.perfumeRepo.Delete(id); err != nil {
+		return err
+	}
+	s.notifier.Notify()
+	return nil
 }
 
 func (s *perfumeService) GetPerfumeWithRelations(id uint) (*models.Perfume, error) {
@@ -58,35 +122,113 @@ func (s *perfumeService) GetAllPerfumesWithRelations() ([]models.Perfume, error)
 	return s.perfumeRepo.GetAllWithRelations()
 }
 
-// GetPerfumesWithPagination retrieves perfumes with pagination and filtering
-func (s *perfumeService) GetPerfumesWithPagination(page, limit int, search, brand, aroma string) ([]models.Perfume, int64, error) {
-	return s.perfumeRepo.GetWithPagination(page, limit, search, brand, aroma)
+// UpdatePerfumeAccords replaces the weighted aroma tags of a perfume
+func (s *perfumeService) UpdatePerfumeAccords(id uint, accords []models.AccordInput) (*models.Perfume, error) {
+	if _, err := s.perfumeRepo.GetByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPerfumeNotFound
+		}
+		return nil, err
+	}
+
+	rows := make([]models.PerfumeAroma, 0, len(accords))
+	for _, accord := range accords {
+		rows = append(rows, models.PerfumeAroma{AromaTagID: accord.AromaTagID, Strength: *accord.Strength})
+	}
+	if err := s.validateAccords(rows); err != nil {
+		return nil, err
+	}
+
+	if err := s.perfumeRepo.ReplaceAccords(id, rows); err != nil {
+		return nil, err
+	}
+	s.notifier.Notify()
+
+	return s.perfumeRepo.GetWithRelations(id)
 }
 
-type PerfumeScore struct {
-	Perfume *models.Perfume
-	Score   float64
+// takeAccords detaches accords submitted with a perfume so they can be stored
+// with their strengths after the perfume itself has been saved
+func (s *perfumeService) takeAccords(perfume *models.Perfume) ([]models.PerfumeAroma, error) {
+	accords := perfume.Accords
+	perfume.Accords = nil
+	if accords == nil {
+		return nil, nil
+	}
+	if err := s.validateAccords(accords); err != nil {
+		return nil, err
+	}
+	return accords, nil
+}
+
+func (s *perfumeService) validateAccords(accords []models.PerfumeAroma) error {
+	seen := make(map[uint]bool, len(accords))
+	for _, accord := range accords {
+		if accord.Strength < 0 || accord.Strength > models.MaxAccordStrength {
+			return fmt.Errorf("%w: strength for aroma %d must be between 0 and %d", ErrInvalidAccords, accord.AromaTagID, models.MaxAccordStrength)
+		}
+		if seen[accord.AromaTagID] {
+			return fmt.Errorf("%w: aroma %d listed more than once", ErrInvalidAccords, accord.AromaTagID)
+		}
+		seen[accord.AromaTagID] = true
+
+		if _, err := s.aromaRepo.GetByID(accord.AromaTagID); err != nil {
+			return fmt.Errorf("%w: aroma %d does not exist", ErrInvalidAccords, accord.AromaTagID)
+		}
+	}
+	return nil
 }
 
-func (s *perfumeService) RecommendPerfumes(aromaSlugs []string) (*models.RecommendationResponse, error) {
-	// Get all perfumes with relations
-	perfumes, err := s.perfumeRepo.GetAllWithRelations()
+// GetPerfumesWithPagination retrieves perfumes with pagination and filtering,
+// leaving out perfumes with an excluded ingredient. It also returns how many
+// perfumes the exclusion removed.
+func (s *perfumeService) GetPerfumesWithPagination(page, limit int, search, brand, aroma string, excludeIngredients []string) ([]models.Perfume, int64, int64, error) {
+	var excludeIDs []uint
+	if exclusion := newIngredientExclusion(excludeIngredients); !exclusion.empty() {
+		// Notes are matched on word boundaries and synonyms, which SQL cannot
+		// express portably, so the excluded perfumes are found in the catalog
+		catalog, err := s.catalog.Snapshot()
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		excludeIDs = exclusion.excludedIDs(catalog.Perfumes)
+	}
+	return s.perfumeRepo.GetWithPagination(page, limit, search, brand, aroma, excludeIDs)
+}
+
+const (
+	defaultRecommendationLimit = 6
+	maxRecommendationLimit     = 50
+)
+
+func (s *perfumeService) RecommendPerfumes(req models.RecommendationRequest) (*models.RecommendationResponse, error) {
+	scorer, err := s.scorers.Get(req.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultRecommendationLimit
+	}
+	if limit > maxRecommendationLimit {
+		limit = maxRecommendationLimit
+	}
+
+	catalog, err := s.catalog.Snapshot()
 	if err != nil {
 		return nil, err
 	}
 
-	// Simple recommendation based on aroma tags
-	var results []models.RecommendationResultResponse
-	for _, perfume := range perfumes {
-		score := 0.5 // Base score
+	perfumes, excludedByIngredients := newIngredientExclusion(req.ExcludeIngredients).filter(catalog.Perfumes)
+	ranked := TopK(perfumes, scorer, NewScoringQuery(req.Aromas), limit)
 
-		// Check if perfume has matching aroma tags
-		for _, aroma := range perfume.AromaTags {
-			for _, targetAroma := range aromaSlugs {
-				if aroma.Slug == targetAroma {
-					score += 0.3
-				}
-			}
+	results := make([]models.RecommendationResultResponse, 0, len(ranked))
+	tagMatches := make(map[string]int)
+	for _, scored := range ranked {
+		perfume := scored.Perfume
+		for _, tag := range scored.Result.MatchedTags {
+			tagMatches[tag]++
 		}
 
 		// Create perfume response
@@ -101,35 +243,48 @@ func (s *perfumeService) RecommendPerfumes(aromaSlugs []string) (*models.Recomme
 			Price:        perfume.Price,
 			ImageURL:     perfume.ImageURL,
 			AromaTags:    perfume.AromaTags,
+			Accords:      perfume.AccordResponses(),
 			Notes:        perfume.Notes,
 			CreatedAt:    perfume.CreatedAt,
 			UpdatedAt:    perfume.UpdatedAt,
 		}
 
+		matched := scored.Result.MatchedTags
+		if matched == nil {
+			matched = []string{}
+		}
 		results = append(results, models.RecommendationResultResponse{
-			Perfume: perfumeResp,
-			Score:   score,
+			Perfume:        perfumeResp,
+			Score:          scored.Result.Total,
+			ScoreBreakdown: scored.Result.Breakdown,
+			MatchedTags:    matched,
 		})
 	}
 
-	// Sort by score
-	for i := 0; i < len(results); i++ {
-		for j := i + 1; j < len(results); j++ {
-			if results[j].Score > results[i].Score {
-				results[i], results[j] = results[j], results[i]
-			}
-		}
-	}
+	return &models.RecommendationResponse{
+		Results:               results,
+		BlendExplanation:      explainRecommendation(req.Aromas, tagMatches, len(results)),
+		Strategy:              scorer.Name(),
+		ExcludedByIngredients: excludedByIngredients,
+	}, nil
+}
 
-	// Limit results
-	if len(results) > 6 {
-		results = results[:6]
+// explainRecommendation summarizes how many results matched each requested aroma
+func explainRecommendation(aromas []string, tagMatches map[string]int, total int) string {
+	if total == 0 {
+		return "No fragrances are available for these aroma preferences yet."
 	}
 
-	return &models.RecommendationResponse{
-		Results:          results,
-		BlendExplanation: "Based on your aroma preferences, we found these matching fragrances.",
-	}, nil
+	var parts []string
+	for _, aroma := range aromas {
+		if count := tagMatches[aroma]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d of %d carry %s", count, total, aroma))
+		}
+	}
+	if len(parts) == 0 {
+		return "None of your selected aromas matched exactly, so these are our closest fragrances."
+	}
+	return "Based on your aroma preferences: " + strings.Join(parts, ", ") + "."
 }
 
 // Helper function to convert string longevity/sillage to int
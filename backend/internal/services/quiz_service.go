@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"sort"
@@ -11,61 +12,149 @@ import (
 	"perfume-website/internal/repositories"
 )
 
+// ErrInvalidQuizRequest is returned when an advanced recommendation request has out-of-range options
+var ErrInvalidQuizRequest = errors.New("invalid recommendation request")
+
+// dislikedDemotion is the share of its score a perfume loses after a thumbs down
+const dislikedDemotion = 0.5
+
 type QuizService struct {
 	quizRepo      repositories.QuizRepository
-	perfumeRepo   repositories.PerfumeRepository
+	catalog       *CatalogService
 	aromaRepo     repositories.AromaRepository
+	settings      *RecommendationSettingsService
+	similarity    *ItemSimilarityService
+	weather       WeatherProvider
+	recommenders  map[string]QuizRecommender
 }
 
-func NewQuizService(quizRepo repositories.QuizRepository, perfumeRepo repositories.PerfumeRepository, aromaRepo repositories.AromaRepository) *QuizService {
-	return &QuizService{
-		quizRepo:    quizRepo,
-		perfumeRepo: perfumeRepo,
-		aromaRepo:   aromaRepo,
-	}
+// NewQuizService creates the quiz recommender. similarity may be nil, which
+// disables the community signal; weather may be nil, which uses the static
+// per-season readings for requests asking for the current weather.
+func NewQuizService(quizRepo repositories.QuizRepository, catalog *CatalogService, aromaRepo repositories.AromaRepository, settings *RecommendationSettingsService, similarity *ItemSimilarityService, weather WeatherProvider) *QuizService {
+	if weather == nil {
+		weather = NewStaticWeatherProvider()
+	}
+	s := &QuizService{
+		quizRepo:     quizRepo,
+		catalog:      catalog,
+		aromaRepo:    aromaRepo,
+		settings:     settings,
+		similarity:   similarity,
+		weather:      weather,
+		recommenders: make(map[string]QuizRecommender),
+	}
+	s.RegisterRecommender(s)
+	s.RegisterRecommender(NewScentProfileRecommender(catalog))
+	return s
+}
+
+// Name identifies the multi-factor algorithm, which QuizService implements itself
+func (s *QuizService) Name() string {
+	return AlgorithmMultiFactor
 }
 
 // GetAdvancedRecommendations generates personalized perfume recommendations based on quiz responses
 func (s *QuizService) GetAdvancedRecommendations(req models.AdvancedRecommendationRequest) (*models.AdvancedRecommendationResponse, error) {
+	// Use one settings snapshot for the whole request so a concurrent update cannot mix weights
+	return s.GetAdvancedRecommendationsWithSettings(req, s.settings.Current())
+}
+
+// GetAdvancedRecommendationsWithSettings scores the request with the given
+// settings in place of the active ones, e.g. for an experiment variant, using
+// the algorithm the request picked
+func (s *QuizService) GetAdvancedRecommendationsWithSettings(req models.AdvancedRecommendationRequest, settings models.RecommendationSettings) (*models.AdvancedRecommendationResponse, error) {
+	recommender, err := s.recommender(req.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return recommender.Recommend(req, settings)
+}
+
+// Recommend ranks perfumes with the multi-factor algorithm
+func (s *QuizService) Recommend(req models.AdvancedRecommendationRequest, settings models.RecommendationSettings) (*models.AdvancedRecommendationResponse, error) {
+	if req.Gift != nil {
+		return s.getGiftRecommendations(req, settings)
+	}
+
+	scoring, err := s.newScoringContext(req, settings)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get personality analysis
 	personality := s.analyzePersonality(req.QuizPreferences)
 
 	// Get candidate perfumes from database
-	perfumes, err := s.getCandidatePerfumes(req)
+	perfumes, excludedByNotes, excludedByIngredients, err := getCandidatePerfumes(s.catalog, req, scoring)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get candidate perfumes: %w", err)
 	}
 
 	// Score each perfume
-	results := s.scorePerfumes(perfumes, req, personality)
+	results := s.scorePerfumes(perfumes, req, personality, scoring)
 
-	// Sort by overall score
+	// Sort by overall score, breaking ties by ID so the order is stable
 	sort.Slice(results, func(i, j int) bool {
-		return results[i].OverallScore > results[j].OverallScore
+		if results[i].OverallScore != results[j].OverallScore {
+			return results[i].OverallScore > results[j].OverallScore
+		}
+		return results[i].Perfume.ID < results[j].Perfume.ID
 	})
 
-	// Limit results
-	if len(results) > req.MaxResults {
-		results = results[:req.MaxResults]
+	// Limit results, re-ranking for variety in brand, category and aroma
+	results = rerankForDiversity(results, req.MaxResults, scoring.diversity, scoring.settings.Diversity)
+
+	factors := []string{"Profile Match", "Season Suitability", "Occasion Appropriateness", "Performance Match", "Uniqueness Bonus"}
+	if scoring.usesNotes() {
+		factors = append(factors, "Note Preferences")
+	}
+	if scoring.usesCommunity() {
+		factors = append(factors, "Community Favourites")
+	}
+	if scoring.usesBudget() {
+		factors = append(factors, "Budget Fit")
+	}
+	if scoring.timeProfile != nil {
+		factors = append(factors, "Time of Day")
+	}
+	if scoring.impressionProfile != nil {
+		factors = append(factors, "Desired Impression")
+	}
+	if scoring.weatherProfile != nil {
+		factors = append(factors, "Weather")
+	}
+	if len(scoring.dislikedIDs) > 0 {
+		factors = append(factors, "Your Feedback")
 	}
 
 	// Generate tips
 	tips := s.generateTips(personality, req.QuizPreferences)
 
 	// Get alternatives (perfumes with slightly different profiles)
-	alternatives := s.getAlternatives(results, req)
+	alternatives := s.getAlternatives(results, perfumes)
 
 	response := &models.AdvancedRecommendationResponse{
 		Results:             results,
 		PersonalityAnalysis: personality,
 		RecommendationLogic: models.RecommendationLogic{
-			Algorithm:          "Multi-Factor Advanced Recommendation v2.0",
-			FactorsConsidered:  []string{"Profile Match", "Season Suitability", "Occasion Appropriateness", "Performance Match", "Uniqueness Bonus"},
-			Weighting:          map[string]float64{"profile": 0.4, "season": 0.2, "occasion": 0.2, "performance": 0.1, "uniqueness": 0.1},
+			Algorithm:          AlgorithmMultiFactor,
+			Version:            "Multi-Factor Advanced Recommendation v2.0",
+			FactorsConsidered:  factors,
+			Weighting:          scoring.weights.AsMap(),
+			Diversity:          scoring.diversity,
 			ProcessDescription: "Our algorithm analyzes your personality traits, scent preferences, and usage patterns to find perfect matches from our database of 940+ perfumes.",
 		},
-		Tips:         tips,
-		Alternatives: alternatives,
+		Tips:                  tips,
+		Alternatives:          alternatives,
+		ExcludedByNotes:       excludedByNotes,
+		ExcludedByIngredients: excludedByIngredients,
+		Season:                scoring.season,
+		Weather:               scoring.weather,
+		Warnings:              scoring.warnings,
+	}
+	if scoring.usesBudget() {
+		response.Budget = summarizeBudget(results, scoring, req.MaxResults)
 	}
 
 	return response, nil
@@ -89,46 +178,48 @@ func (s *QuizService) GetQuizStatistics() (map[string]interface{}, error) {
 	return s.quizRepo.GetQuizStatistics()
 }
 
+// personalityType is one of the scent personalities the quiz assigns. Names
+// and descriptions are translatable under the personality entity type.
+type personalityType struct {
+	name        string
+	traits      []string
+	description string
+	bestNotes   []string
+}
+
+var personalityTypes = []personalityType{
+	{"The Romantic Elegant", []string{"Sophisticated", "Charming", "Timeless"},
+		"You appreciate classic, romantic fragrances that exude elegance and grace.",
+		[]string{"Rose", "Jasmine", "Vanilla", "Amber"}},
+	{"The Adventurous Explorer", []string{"Bold", "Curious", "Free-spirited"},
+		"You love unique, unconventional scents that tell a story and make a statement.",
+		[]string{"Leather", "Incense", "Oud", "Spices"}},
+	{"The Modern Professional", []string{"Confident", "Sophisticated", "Ambitious"},
+		"You prefer clean, contemporary fragrances that project success and refinement.",
+		[]string{"Citrus", "Vetiver", "Sandalwood", "Musk"}},
+	{"The Creative Soul", []string{"Artistic", "Expressive", "Unique"},
+		"You're drawn to artistic, complex compositions that inspire creativity and individuality.",
+		[]string{"Patchouli", "Incense", "Unusual Florals", "Gourmand"}},
+	{"The Natural Spirit", []string{"Grounded", "Authentic", "Harmonious"},
+		"You love earthy, natural scents that connect you to nature and create a sense of peace.",
+		[]string{"Green Notes", "Woods", "Herbs", "Earth"}},
+	{"The Charismatic Socialite", []string{"Magnetic", "Energetic", "Sociable"},
+		"You enjoy bright, alluring fragrances that make you memorable and draw people in.",
+		[]string{"Fruits", "Florals", "Sweet Notes", "Spices"}},
+}
+
 // GetPersonalityTypes returns available personality types
 func (s *QuizService) GetPersonalityTypes() []map[string]interface{} {
-	return []map[string]interface{}{
-		{
-			"type":        "The Romantic Elegant",
-			"traits":      []string{"Sophisticated", "Charming", "Timeless"},
-			"description": "You appreciate classic, romantic fragrances that exude elegance and grace.",
-			"best_notes":  []string{"Rose", "Jasmine", "Vanilla", "Amber"},
-		},
-		{
-			"type":        "The Adventurous Explorer",
-			"traits":      []string{"Bold", "Curious", "Free-spirited"},
-			"description": "You love unique, unconventional scents that tell a story and make a statement.",
-			"best_notes":  []string{"Leather", "Incense", "Oud", "Spices"},
-		},
-		{
-			"type":        "The Modern Professional",
-			"traits":      []string{"Confident", "Sophisticated", "Ambitious"},
-			"description": "You prefer clean, contemporary fragrances that project success and refinement.",
-			"best_notes":  []string{"Citrus", "Vetiver", "Sandalwood", "Musk"},
-		},
-		{
-			"type":        "The Creative Soul",
-			"traits":      []string{"Artistic", "Expressive", "Unique"},
-			"description": "You're drawn to artistic, complex compositions that inspire creativity and individuality.",
-			"best_notes":  []string{"Patchouli", "Incense", "Unusual Florals", "Gourmand"},
-		},
-		{
-			"type":        "The Natural Spirit",
-			"traits":      []string{"Grounded", "Authentic", "Harmonious"},
-			"description": "You love earthy, natural scents that connect you to nature and create a sense of peace.",
-			"best_notes":  []string{"Green Notes", "Woods", "Herbs", "Earth"},
-		},
-		{
-			"type":        "The Charismatic Socialite",
-			"traits":      []string{"Magnetic", "Energetic", "Sociable"},
-			"description": "You enjoy bright, alluring fragrances that make you memorable and draw people in.",
-			"best_notes":  []string{"Fruits", "Florals", "Sweet Notes", "Spices"},
-		},
-	}
+	types := make([]map[string]interface{}, 0, len(personalityTypes))
+	for _, personality := range personalityTypes {
+		types = append(types, map[string]interface{}{
+			"type":        personality.name,
+			"traits":      personality.traits,
+			"description": personality.description,
+			"best_notes":  personality.bestNotes,
+		})
+	}
+	return types
 }
 
 // Private helper methods
@@ -208,34 +299,149 @@ func (s *QuizService) analyzePersonality(pref models.QuizPreferences) models.Per
 }
 
 func (s *QuizService) getStyleDescription(personality string, scentProfile []string) string {
-	descriptions := map[string]string{
-		"The Romantic Elegant":  "You appreciate classic, romantic fragrances that exude elegance and grace.",
-		"The Adventurous Explorer": "You love unique, unconventional scents that tell a story and make a statement.",
-		"The Modern Professional": "You prefer clean, contemporary fragrances that project success and refinement.",
-		"The Creative Soul":     "You're drawn to artistic, complex compositions that inspire creativity and individuality.",
-		"The Natural Spirit":    "You love earthy, natural scents that connect you to nature and create a sense of peace.",
-		"The Charismatic Socialite": "You enjoy bright, alluring fragrances that make you memorable and draw people in.",
+	for _, candidate := range personalityTypes {
+		if candidate.name == personality {
+			return candidate.description
+		}
+	}
+	return "You have unique preferences that deserve special consideration."
+}
+
+// scoringContext holds the per-request inputs shared by every scored perfume
+type scoringContext struct {
+	settings          models.RecommendationSettings
+	weights           models.RecommendationWeights // normalized, with unused components zeroed
+	preferredNotes    []string
+	avoidedNotes      []string
+	likedIDs          []uint
+	dislikedIDs       map[uint]bool
+	diversity         float64
+	priceRange        string
+	budgetBand        models.PriceBand
+	budgetMode        string
+	season            *models.SeasonInfo
+	timeOfDay         string
+	timeProfile       *wearProfile
+	impression        string
+	impressionProfile *wearProfile
+	weather           *models.WeatherInfo
+	weatherProfile    *wearProfile
+	warnings          []string // request options that were ignored, for the response
+}
+
+// newScoringContext validates the request options and resolves them against the settings
+func (s *QuizService) newScoringContext(req models.AdvancedRecommendationRequest, settings models.RecommendationSettings) (scoringContext, error) {
+	ctx := scoringContext{
+		settings:       settings,
+		preferredNotes: normalizeNoteTerms(req.PreferredNotes),
+		avoidedNotes:   normalizeNoteTerms(req.AvoidedNotes),
+	}
+	if s.similarity != nil {
+		ctx.likedIDs = append(append([]uint{}, req.LikedPerfumeIDs...), req.UpvotedPerfumeIDs...)
+	}
+	if len(req.DislikedPerfumeIDs) > 0 {
+		ctx.dislikedIDs = make(map[uint]bool, len(req.DislikedPerfumeIDs))
+		for _, id := range req.DislikedPerfumeIDs {
+			ctx.dislikedIDs[id] = true
+		}
 	}
 
-	if desc, exists := descriptions[personality]; exists {
-		return desc
+	ctx.diversity = ctx.settings.Diversity.Default
+	if req.Diversity != nil {
+		ctx.diversity = *req.Diversity
+		if math.IsNaN(ctx.diversity) || ctx.diversity < 0 || ctx.diversity > 1 {
+			return ctx, fmt.Errorf("%w: diversity must be between 0 and 1", ErrInvalidQuizRequest)
+		}
 	}
-	return "You have unique preferences that deserve special consideration."
+
+	// An unknown price range only loses the budget component, like a missing
+	// one; the response warns that it was ignored
+	if priceRange := strings.ToLower(strings.TrimSpace(req.PriceRange)); priceRange != "" {
+		if band, ok := ctx.settings.Budget.Band(priceRange); ok {
+			ctx.priceRange, ctx.budgetBand = priceRange, band
+		} else {
+			ctx.warnings = append(ctx.warnings, fmt.Sprintf("Ignored unknown price_range '%s'", req.PriceRange))
+		}
+	}
+	season, err := resolveSeason(req, ctx.settings.Season, time.Now())
+	if err != nil {
+		return ctx, err
+	}
+	ctx.season = season
+
+	ctx.timeOfDay, ctx.timeProfile = ctx.optionalWearProfile(timeOfDayProfiles, "time_of_day", req.TimeOfDay)
+	ctx.impression, ctx.impressionProfile = ctx.optionalWearProfile(impressionProfiles, "desired_impression", req.DesiredImpression)
+	if ctx.weather, err = resolveWeather(req, season, s.weather); err != nil {
+		return ctx, err
+	}
+	if ctx.weather != nil {
+		profile := weatherProfiles[ctx.weather.Condition]
+		ctx.weatherProfile = &profile
+	}
+
+	switch mode := strings.ToLower(strings.TrimSpace(req.BudgetMode)); mode {
+	case "", models.BudgetModeSoft:
+		ctx.budgetMode = models.BudgetModeSoft
+	case models.BudgetModeStrict:
+		ctx.budgetMode = models.BudgetModeStrict
+	default:
+		return ctx, fmt.Errorf("%w: budget_mode must be '%s' or '%s'", ErrInvalidQuizRequest, models.BudgetModeSoft, models.BudgetModeStrict)
+	}
+
+	// Optional components only count when the request gave them something to measure
+	weights := ctx.settings.Weights
+	if !ctx.usesNotes() {
+		weights.Notes = 0
+	}
+	if !ctx.usesCommunity() {
+		weights.Community = 0
+	}
+	if !ctx.usesBudget() {
+		weights.Budget = 0
+	}
+	if ctx.timeProfile == nil {
+		weights.TimeOfDay = 0
+	}
+	if ctx.impressionProfile == nil {
+		weights.Impression = 0
+	}
+	if ctx.weatherProfile == nil {
+		weights.Weather = 0
+	}
+	ctx.weights = weights.Normalized()
+
+	return ctx, nil
+}
+
+func (ctx scoringContext) usesNotes() bool {
+	return len(ctx.preferredNotes) > 0 || len(ctx.avoidedNotes) > 0
+}
+
+func (ctx scoringContext) usesCommunity() bool {
+	return len(ctx.likedIDs) > 0
+}
+
+func (ctx scoringContext) usesBudget() bool {
+	return ctx.priceRange != ""
 }
 
-func (s *QuizService) getCandidatePerfumes(req models.AdvancedRecommendationRequest) ([]models.Perfume, error) {
-	// Get all perfumes from database - in a real implementation, this would be more sophisticated
-	perfumes, err := s.perfumeRepo.GetAllWithRelations()
+// getCandidatePerfumes returns the perfumes eligible for recommendation and how
+// many were dropped because they contain a strongly avoided note or an excluded
+// ingredient. The slice may be the shared catalog snapshot, so it must not be modified.
+func getCandidatePerfumes(catalog *CatalogService, req models.AdvancedRecommendationRequest, scoring scoringContext) ([]models.Perfume, int, int, error) {
+	snapshot, err := catalog.Snapshot()
 	if err != nil {
-		return nil, err
+		return nil, 0, 0, err
 	}
+	perfumes := snapshot.Perfumes
 
-	// Filter out excluded IDs
-	if len(req.ExcludeIDs) > 0 {
+	// Filter out excluded IDs and the perfumes the user already loves
+	excludeIDs := append(append([]uint{}, req.ExcludeIDs...), req.LikedPerfumeIDs...)
+	if len(excludeIDs) > 0 {
 		var filtered []models.Perfume
 		for _, perfume := range perfumes {
 			excluded := false
-			for _, id := range req.ExcludeIDs {
+			for _, id := range excludeIDs {
 				if perfume.ID == id {
 					excluded = true
 					break
@@ -248,41 +454,175 @@ func (s *QuizService) getCandidatePerfumes(req models.AdvancedRecommendationRequ
 		perfumes = filtered
 	}
 
-	return perfumes, nil
+	// Strongly avoided notes are a hard exclusion
+	excludedByNotes := 0
+	if strict := normalizeNoteTerms(req.StronglyAvoidedNotes); len(strict) > 0 {
+		var filtered []models.Perfume
+		for _, perfume := range perfumes {
+			if containsAnyNote(perfume, strict) {
+				excludedByNotes++
+				continue
+			}
+			filtered = append(filtered, perfume)
+		}
+		perfumes = filtered
+	}
+
+	// So are excluded ingredients, which also match synonyms and aroma slugs
+	perfumes, excludedByIngredients := newIngredientExclusion(req.ExcludeIngredients).filter(perfumes)
+
+	// A strict budget keeps only perfumes inside the price band
+	if scoring.usesBudget() && scoring.budgetMode == models.BudgetModeStrict {
+		var filtered []models.Perfume
+		for _, perfume := range perfumes {
+			if scoring.budgetBand.Contains(perfume.Price) {
+				filtered = append(filtered, perfume)
+			}
+		}
+		perfumes = filtered
+	}
+
+	return perfumes, excludedByNotes, excludedByIngredients, nil
 }
 
-func (s *QuizService) scorePerfumes(perfumes []models.Perfume, req models.AdvancedRecommendationRequest, personality models.PersonalityAnalysis) []models.AdvancedRecommendationResult {
+func (s *QuizService) scorePerfumes(perfumes []models.Perfume, req models.AdvancedRecommendationRequest, personality models.PersonalityAnalysis, scoring scoringContext) []models.AdvancedRecommendationResult {
 	var results []models.AdvancedRecommendationResult
 
-	for i, perfume := range perfumes {
-		result := s.scoreSinglePerfume(perfume, req, personality, i+1)
+	for _, perfume := range perfumes {
+		result := s.scoreSinglePerfume(perfume, req, personality, scoring)
 		results = append(results, result)
 	}
 
 	return results
 }
 
-func (s *QuizService) scoreSinglePerfume(perfume models.Perfume, req models.AdvancedRecommendationRequest, personality models.PersonalityAnalysis, rank int) models.AdvancedRecommendationResult {
-	// Profile Match (40% weight)
-	profileMatch := s.calculateProfileMatch(perfume, req.QuizPreferences)
+func (s *QuizService) scoreSinglePerfume(perfume models.Perfume, req models.AdvancedRecommendationRequest, personality models.PersonalityAnalysis, scoring scoringContext) models.AdvancedRecommendationResult {
+	thresholds := scoring.settings.Thresholds
+
+	// Profile Match
+	profileMatch, profileWhy := s.calculateProfileMatch(perfume, req.QuizPreferences)
+
+	// Season Match
+	seasonMatch, seasonWhy := s.calculateSeasonMatch(perfume, scoring.season.Season)
+
+	// Occasion Match
+	occasionMatch, occasionWhy := s.calculateOccasionMatch(perfume, req.CurrentSituation, req.QuizPreferences, thresholds)
 
-	// Season Match (20% weight)
-	seasonMatch := s.calculateSeasonMatch(perfume, req.Season)
+	// Performance Match
+	performanceMatch, performanceWhy := s.calculatePerformanceMatch(perfume, req.QuizPreferences)
 
-	// Occasion Match (20% weight)
-	occasionMatch := s.calculateOccasionMatch(perfume, req.CurrentSituation, req.QuizPreferences)
+	// Uniqueness Bonus
+	uniquenessBonus, uniquenessWhy := s.calculateUniquenessBonus(perfume, req.QuizPreferences, thresholds)
 
-	// Performance Match (10% weight)
-	performanceMatch := s.calculatePerformanceMatch(perfume, req.QuizPreferences)
+	explanations := make([]models.Explanation, 0, len(profileWhy)+len(seasonWhy)+len(occasionWhy)+len(performanceWhy)+len(uniquenessWhy))
+	explanations = append(explanations, profileWhy...)
+	explanations = append(explanations, seasonWhy...)
+	explanations = append(explanations, occasionWhy...)
+	explanations = append(explanations, performanceWhy...)
+	explanations = append(explanations, uniquenessWhy...)
 
-	// Uniqueness Bonus (10% weight)
-	uniquenessBonus := s.calculateUniquenessBonus(perfume, req.QuizPreferences)
+	// Note Match
+	var noteMatch float64
+	var matchedNotes []models.MatchedNote
+	if scoring.usesNotes() {
+		noteMatch, matchedNotes = calculateNoteMatch(perfume, scoring.preferredNotes, scoring.avoidedNotes)
+		explanations = append(explanations, explainMatchedNotes(matchedNotes)...)
+	}
+
+	// Community Match
+	var communityMatch float64
+	var communitySource string
+	if scoring.usesCommunity() {
+		communityMatch, communitySource = s.similarity.CommunityScore(&perfume, scoring.likedIDs)
+		if communityMatch > 0 {
+			code := "community.content"
+			if communitySource == models.SimilaritySourceCommunity {
+				code = "community.co_rated"
+			}
+			explanations = append(explanations, explain(code, map[string]any{"similarity": roundTo2(communityMatch)}))
+		}
+	}
 
-	// Overall score calculation
-	overallScore := (profileMatch * 0.4) + (seasonMatch * 0.2) + (occasionMatch * 0.2) + (performanceMatch * 0.1) + (uniquenessBonus * 0.1)
+	// Budget Fit
+	var budgetMatch float64
+	var warnings []string
+	if scoring.usesBudget() {
+		budgetMatch = budgetFit(perfume.Price, scoring.budgetBand, scoring.settings.Budget.Tolerance)
+		code := "budget.within"
+		if !scoring.budgetBand.Contains(perfume.Price) {
+			code = "budget.outside"
+			warnings = append(warnings, fmt.Sprintf("Outside your %s price range", scoring.priceRange))
+		}
+		explanations = append(explanations, explain(code, map[string]any{
+			"price":       perfume.Price,
+			"price_range": scoring.priceRange,
+			"range":       priceBandLabel(scoring.budgetBand),
+		}))
+	}
+
+	// Time of Day and Desired Impression
+	var timeOfDayMatch, impressionMatch float64
+	if scoring.timeProfile != nil {
+		var why []models.Explanation
+		timeOfDayMatch, why = calculateWearMatch(perfume, scoring.timeProfile, "time_of_day", scoring.timeOfDay)
+		explanations = append(explanations, why...)
+	}
+	if scoring.impressionProfile != nil {
+		var why []models.Explanation
+		impressionMatch, why = calculateWearMatch(perfume, scoring.impressionProfile, "impression", scoring.impression)
+		explanations = append(explanations, why...)
+	}
+
+	// Weather
+	var weatherMatch float64
+	if scoring.weatherProfile != nil {
+		var why []models.Explanation
+		condition := weatherLabel(scoring.weather.Condition)
+		weatherMatch, why = calculateWearMatch(perfume, scoring.weatherProfile, "weather", condition)
+		explanations = append(explanations, why...)
+		if isHotWeather(scoring.weather.Condition) && sillageLevel(perfume.Sillage) >= sillageLevel("heavy") {
+			warnings = append(warnings, fmt.Sprintf("Its %s sillage can turn cloying in %s weather", strings.ToLower(perfume.Sillage), condition))
+			explanations = append(explanations, explain("weather.too_heavy", map[string]any{"sillage": perfume.Sillage, "weather": condition}))
+		}
+	}
+	if perfume.Price < thresholds.ValuePriceMax {
+		explanations = append(explanations, explain("price.value", map[string]any{"price": perfume.Price}))
+	}
+
+	// Overall score calculation with the configured (normalized) weights
+	components := map[string]float64{
+		"profile":     profileMatch,
+		"season":      seasonMatch,
+		"occasion":    occasionMatch,
+		"performance": performanceMatch,
+		"uniqueness":  uniquenessBonus,
+		"notes":       noteMatch,
+		"community":   communityMatch,
+		"budget":      budgetMatch,
+		"time_of_day": timeOfDayMatch,
+		"impression":  impressionMatch,
+		"weather":     weatherMatch,
+	}
+	overallScore := 0.0
+	breakdown := make(map[string]float64)
+	weights := scoring.weights.AsMap()
+	for _, name := range models.WeightComponents {
+		if weight := weights[name]; weight > 0 {
+			breakdown[name] = components[name] * weight
+			overallScore += breakdown[name]
+		}
+	}
+	if scoring.dislikedIDs[perfume.ID] {
+		// Recorded as a negative component so the breakdown still adds up to the score
+		breakdown["feedback"] = -overallScore * dislikedDemotion
+		overallScore += breakdown["feedback"]
+		warnings = append(warnings, "You gave this a thumbs down before")
+		explanations = append(explanations, explain("feedback.disliked", nil))
+	}
 
 	// Generate match reasons
-	matchReasons := s.generateMatchReasons(perfume, profileMatch, seasonMatch, occasionMatch, req.QuizPreferences)
+	matchReasons := s.generateMatchReasons(perfume, components, breakdown, explanations, thresholds)
+	warnings = append(warnings, describeAvoidedNotes(matchedNotes)...)
 
 	// Determine best for and wear timing
 	bestFor := s.getBestFor(perfume, req.QuizPreferences)
@@ -296,185 +636,208 @@ func (s *QuizService) scoreSinglePerfume(perfume models.Perfume, req models.Adva
 		OccasionMatch:    occasionMatch,
 		PerformanceMatch: performanceMatch,
 		UniquenessBonus:  uniquenessBonus,
+		NoteMatch:        noteMatch,
+		MatchedNotes:     matchedNotes,
+		CommunityMatch:   communityMatch,
+		CommunitySource:  communitySource,
+		BudgetFit:        budgetMatch,
+		TimeOfDayMatch:   timeOfDayMatch,
+		ImpressionMatch:  impressionMatch,
+		WeatherMatch:     weatherMatch,
+		ScoreBreakdown:   breakdown,
+		Explanations:     explanations,
 		MatchReasons:     matchReasons,
+		Warnings:         warnings,
 		BestFor:          bestFor,
 		WearTiming:       wearTiming,
 		Longevity:        perfume.Longevity,
 		Projection:       perfume.Sillage,
 		Confidence:       math.Min(overallScore+0.1, 1.0),
-		Rank:             rank,
 	}
 }
 
-func (s *QuizService) calculateProfileMatch(perfume models.Perfume, pref models.QuizPreferences) float64 {
+func (s *QuizService) calculateProfileMatch(perfume models.Perfume, pref models.QuizPreferences) (float64, []models.Explanation) {
 	score := 0.5 // Base score
+	var explanations []models.Explanation
 
-	// Check aroma tags match
-	perfumeAromas := make(map[string]bool)
-	for _, aroma := range perfume.AromaTags {
-		perfumeAromas[strings.ToLower(aroma.Name)] = true
-	}
+	// Check aroma tags match, keeping the accord strength as a 0-1 weight
+	strengths := perfume.AccordStrengths()
 
-	// Match with user preferences
-	if pref.LightFresh && (perfumeAromas["citrus"] || perfumeAromas["fresh"] || perfumeAromas["aquatic"]) {
-		score += 0.2
-	}
-	if pref.WarmSpicy && (perfumeAromas["spicy"] || perfumeAromas["warm"] || perfumeAromas["oriental"]) {
-		score += 0.2
-	}
-	if pref.SweetGourmand && (perfumeAromas["sweet"] || perfumeAromas["vanilla"] || perfumeAromas["gourmand"]) {
-		score += 0.2
-	}
-	if pref.WoodyEarthy && (perfumeAromas["woody"] || perfumeAromas["earthy"] || perfumeAromas["cedar"]) {
-		score += 0.2
-	}
-	if pref.FloralRomantic && (perfumeAromas["floral"] || perfumeAromas["rose"] || perfumeAromas["jasmine"]) {
-		score += 0.2
-	}
-	if pref.CitrusEnergizing && (perfumeAromas["citrus"] || perfumeAromas["bergamot"] || perfumeAromas["lemon"]) {
-		score += 0.2
+	// Match with user preferences, crediting the most prominent matching accord
+	for _, family := range profileFamilies {
+		if !family.wanted(pref) {
+			continue
+		}
+		weight, accord := 0.0, ""
+		for _, aroma := range perfume.AromaTags {
+			if !containsFold(family.aromas, aroma.Name) {
+				continue
+			}
+			if strength := float64(strengths[aroma.ID]) / models.MaxAccordStrength; strength > weight {
+				weight, accord = strength, aroma.Name
+			}
+		}
+		if weight > 0 {
+			score += 0.2 * weight
+			explanations = append(explanations, explain("profile.accord_match", map[string]any{
+				"preference": family.label,
+				"accords":    []string{accord},
+				"strength":   int(weight * models.MaxAccordStrength),
+			}))
+		}
 	}
 
-	return math.Min(score, 1.0)
+	return math.Min(score, 1.0), explanations
 }
 
-func (s *QuizService) calculateSeasonMatch(perfume models.Perfume, season string) float64 {
+func (s *QuizService) calculateSeasonMatch(perfume models.Perfume, season string) (float64, []models.Explanation) {
 	// Simple season matching based on fragrance characteristics
 	score := 0.6 // Base score
 
-	switch season {
-	case "spring":
-		if strings.Contains(strings.ToLower(perfume.Description), "fresh") ||
-			strings.Contains(strings.ToLower(perfume.Description), "floral") {
-			score += 0.3
-		}
-	case "summer":
-		if strings.Contains(strings.ToLower(perfume.Description), "light") ||
-			strings.Contains(strings.ToLower(perfume.Description), "citrus") ||
-			strings.Contains(strings.ToLower(perfume.Description), "aquatic") {
-			score += 0.3
-		}
-	case "fall":
-		if strings.Contains(strings.ToLower(perfume.Description), "warm") ||
-			strings.Contains(strings.ToLower(perfume.Description), "spicy") ||
-			strings.Contains(strings.ToLower(perfume.Description), "woody") {
-			score += 0.3
-		}
-	case "winter":
-		if strings.Contains(strings.ToLower(perfume.Description), "rich") ||
-			strings.Contains(strings.ToLower(perfume.Description), "deep") ||
-			strings.Contains(strings.ToLower(perfume.Description), "oriental") {
-			score += 0.3
-		}
+	cues := matchedCues(perfume.Description, seasonCues[season])
+	if len(cues) == 0 {
+		return score, nil
 	}
+	score += 0.3
 
-	return math.Min(score, 1.0)
+	return math.Min(score, 1.0), []models.Explanation{
+		explain("season.cue", map[string]any{"season": seasonLabel(season), "cues": cues}),
+	}
 }
 
-func (s *QuizService) calculateOccasionMatch(perfume models.Perfume, occasion string, pref models.QuizPreferences) float64 {
+func (s *QuizService) calculateOccasionMatch(perfume models.Perfume, occasion string, pref models.QuizPreferences, thresholds models.RecommendationThresholds) (float64, []models.Explanation) {
 	score := 0.6 // Base score
+	var explanations []models.Explanation
 
 	// Match perfume characteristics with occasion
 	switch occasion {
 	case "work":
 		if perfume.Sillage == "Light" || perfume.Sillage == "Medium" {
 			score += 0.3
+			explanations = append(explanations, explain("occasion.sillage", map[string]any{"sillage": perfume.Sillage, "occasion": occasion}))
 		}
-		if strings.Contains(strings.ToLower(perfume.Brand), "professional") ||
-			strings.Contains(strings.ToLower(perfume.Description), "clean") {
+		if cues := matchedCues(perfume.Brand+" "+perfume.Description, []string{"professional", "clean"}); len(cues) > 0 {
 			score += 0.1
+			explanations = append(explanations, explain("occasion.cue", map[string]any{"cues": cues, "occasion": occasion}))
 		}
 	case "date":
-		if strings.Contains(strings.ToLower(perfume.Description), "romantic") ||
-			strings.Contains(strings.ToLower(perfume.Description), "seductive") {
+		if cues := matchedCues(perfume.Description, []string{"romantic", "seductive"}); len(cues) > 0 {
 			score += 0.3
+			explanations = append(explanations, explain("occasion.cue", map[string]any{"cues": cues, "occasion": occasion}))
 		}
 	case "casual":
-		if perfume.Price < 150 && perfume.Sillage == "Light" {
+		if perfume.Price < thresholds.CasualPriceMax && perfume.Sillage == "Light" {
 			score += 0.3
+			explanations = append(explanations, explain("occasion.casual_fit", map[string]any{"price": perfume.Price, "limit": thresholds.CasualPriceMax}))
 		}
 	case "special":
-		if perfume.Price > 100 || perfume.Sillage == "Heavy" {
+		if perfume.Price > thresholds.SpecialPriceMin {
+			score += 0.3
+			explanations = append(explanations, explain("occasion.special_price", map[string]any{"price": perfume.Price, "limit": thresholds.SpecialPriceMin}))
+		} else if perfume.Sillage == "Heavy" {
 			score += 0.3
+			explanations = append(explanations, explain("occasion.sillage", map[string]any{"sillage": perfume.Sillage, "occasion": occasion}))
 		}
 	}
 
-	return math.Min(score, 1.0)
+	return math.Min(score, 1.0), explanations
 }
 
-func (s *QuizService) calculatePerformanceMatch(perfume models.Perfume, pref models.QuizPreferences) float64 {
+func (s *QuizService) calculatePerformanceMatch(perfume models.Perfume, pref models.QuizPreferences) (float64, []models.Explanation) {
 	score := 0.5 // Base score
+	var explanations []models.Explanation
 
 	// Longevity match
+	longevityMatched := false
 	switch pref.Longevity {
 	case "light":
-		if perfume.Longevity == "Light" {
-			score += 0.3
-		}
+		longevityMatched = perfume.Longevity == "Light"
 	case "medium":
-		if perfume.Longevity == "Medium" {
-			score += 0.3
-		}
+		longevityMatched = perfume.Longevity == "Medium"
 	case "long":
-		if perfume.Longevity == "Long" || perfume.Longevity == "Very Long" {
-			score += 0.3
-		}
+		longevityMatched = perfume.Longevity == "Long" || perfume.Longevity == "Very Long"
+	}
+	if longevityMatched {
+		score += 0.3
+		explanations = append(explanations, explain("performance.longevity", map[string]any{"longevity": perfume.Longevity, "preference": pref.Longevity}))
 	}
 
 	// Sillage match
+	sillageMatched := false
 	switch pref.Sillage {
 	case "subtle":
-		if perfume.Sillage == "Light" {
-			score += 0.2
-		}
+		sillageMatched = perfume.Sillage == "Light"
 	case "moderate":
-		if perfume.Sillage == "Medium" {
-			score += 0.2
-		}
+		sillageMatched = perfume.Sillage == "Medium"
 	case "heavy":
-		if perfume.Sillage == "Heavy" || perfume.Sillage == "Very Heavy" {
-			score += 0.2
-		}
+		sillageMatched = perfume.Sillage == "Heavy" || perfume.Sillage == "Very Heavy"
+	}
+	if sillageMatched {
+		score += 0.2
+		explanations = append(explanations, explain("performance.sillage", map[string]any{"sillage": perfume.Sillage, "preference": pref.Sillage}))
 	}
 
-	return math.Min(score, 1.0)
+	return math.Min(score, 1.0), explanations
 }
 
-func (s *QuizService) calculateUniquenessBonus(perfume models.Perfume, pref models.QuizPreferences) float64 {
+func (s *QuizService) calculateUniquenessBonus(perfume models.Perfume, pref models.QuizPreferences, thresholds models.RecommendationThresholds) (float64, []models.Explanation) {
 	if pref.Unique {
 		// Bonus for less common brands or unique compositions
-		if perfume.Price > 200 {
-			return 0.8
+		if perfume.Price > thresholds.PremiumPriceMin {
+			return 0.8, []models.Explanation{explain("uniqueness.premium", map[string]any{"price": perfume.Price})}
 		}
-		return 0.6
+		return 0.6, []models.Explanation{explain("uniqueness.distinctive", nil)}
 	}
 
 	if pref.SafeBet {
 		// Bonus for popular, well-known options
-		if perfume.Price < 100 {
-			return 0.7
+		if perfume.Price < thresholds.SafeBetPriceMax {
+			return 0.7, []models.Explanation{explain("uniqueness.safe_bet", map[string]any{"price": perfume.Price})}
 		}
 	}
 
-	return 0.5
+	return 0.5, nil
 }
 
-func (s *QuizService) generateMatchReasons(perfume models.Perfume, profileMatch, seasonMatch, occasionMatch float64, pref models.QuizPreferences) []string {
-	var reasons []string
-
-	if profileMatch > 0.7 {
-		reasons = append(reasons, "Perfect match for your scent preferences")
-	}
-	if seasonMatch > 0.7 {
-		reasons = append(reasons, fmt.Sprintf("Ideal for %s weather", s.getCurrentSeason()))
-	}
-	if occasionMatch > 0.7 {
-		reasons = append(reasons, "Perfect for your intended occasion")
+// maxMatchReasons caps how many match reasons a result lists
+const maxMatchReasons = 4
+
+// generateMatchReasons picks the messages of the positive explanations, one
+// per component: first the score components that beat the MatchReason
+// threshold, by their weighted contribution to the score, then the
+// informational ones such as price.value
+func (s *QuizService) generateMatchReasons(perfume models.Perfume, components, breakdown map[string]float64, explanations []models.Explanation, thresholds models.RecommendationThresholds) []string {
+	type candidate struct {
+		message      string
+		contribution float64
+	}
+	var candidates []candidate
+	seen := make(map[string]bool)
+	for _, explanation := range explanations {
+		if explanation.Effect != models.ExplanationPositive || seen[explanation.Component] {
+			continue
+		}
+		contribution, weighted := breakdown[explanation.Component]
+		if match, scored := components[explanation.Component]; !scored {
+			contribution = -1
+		} else if !weighted || match <= thresholds.MatchReason {
+			continue
+		}
+		seen[explanation.Component] = true
+		candidates = append(candidates, candidate{explanation.Message, contribution})
 	}
-	if perfume.Price < 100 {
-		reasons = append(reasons, "Great value for your budget")
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].contribution > candidates[j].contribution
+	})
+
+	var reasons []string
+	for _, candidate := range candidates {
+		if len(reasons) == maxMatchReasons {
+			break
+		}
+		reasons = append(reasons, candidate.message)
 	}
-	if perfume.Longevity == "Long" || perfume.Longevity == "Very Long" {
+	if len(reasons) < maxMatchReasons && matchesPerformance([]string{"long", "very long"}, perfume.Longevity) {
 		reasons = append(reasons, "Long-lasting fragrance")
 	}
 
@@ -549,16 +912,10 @@ func (s *QuizService) generateTips(personality models.PersonalityAnalysis, pref
 	return tips
 }
 
-func (s *QuizService) getAlternatives(results []models.AdvancedRecommendationResult, req models.AdvancedRecommendationRequest) []models.Perfume {
-	// Get some alternative perfumes with slightly different profiles
-	alternatives, err := s.getCandidatePerfumes(req)
-	if err != nil {
-		return []models.Perfume{}
-	}
-
+func (s *QuizService) getAlternatives(results []models.AdvancedRecommendationResult, candidates []models.Perfume) []models.Perfume {
 	// Return a few alternatives that weren't in the main results
 	var alts []models.Perfume
-	for _, alt := range alternatives {
+	for _, alt := range candidates {
 		inResults := false
 		for _, res := range results {
 			if alt.ID == res.Perfume.ID {
@@ -573,8 +930,3 @@ func (s *QuizService) getAlternatives(results []models.AdvancedRecommendationRes
 
 	return alts
 }
-
-func (s *QuizService) getCurrentSeason() string {
-	// Simple implementation - in real system, use current date
-	return "current season"
-}
\ No newline at end of file
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sort"
+
+	"perfume-website/internal/models"
+)
+
+// DefaultScoringStrategy is used when a recommendation request names no strategy
+const DefaultScoringStrategy = "default"
+
+// ErrUnknownStrategy is returned when a request names a strategy that is not registered
+var ErrUnknownStrategy = errors.New("unknown scoring strategy")
+
+// ScoringQuery is the normalized input shared by every scoring component
+type ScoringQuery struct {
+	AromaSlugs []string
+	aromaSet   map[string]bool
+}
+
+// NewScoringQuery builds a query from the requested aroma slugs
+func NewScoringQuery(aromaSlugs []string) ScoringQuery {
+	set := make(map[string]bool, len(aromaSlugs))
+	for _, slug := range aromaSlugs {
+		set[slug] = true
+	}
+	return ScoringQuery{AromaSlugs: aromaSlugs, aromaSet: set}
+}
+
+// Wants reports whether the query asked for the aroma slug
+func (q ScoringQuery) Wants(slug string) bool {
+	return q.aromaSet[slug]
+}
+
+// ComponentScore is the contribution of one scoring component
+type ComponentScore struct {
+	Value       float64
+	MatchedTags []string
+}
+
+// ScoreComponent computes one named part of a perfume's score
+type ScoreComponent interface {
+	Name() string
+	Score(perfume *models.Perfume, query ScoringQuery) ComponentScore
+}
+
+// ScoreResult is the total score of a perfume with its per-component breakdown
+type ScoreResult struct {
+	Total       float64
+	Breakdown   map[string]float64
+	MatchedTags []string
+}
+
+// Scorer ranks perfumes for /api/recommend
+type Scorer interface {
+	Name() string
+	Score(perfume *models.Perfume, query ScoringQuery) ScoreResult
+}
+
+// WeightedComponent pairs a component with the multiplier applied to its value
+type WeightedComponent struct {
+	Component ScoreComponent
+	Weight    float64
+}
+
+// CompositeScorer adds up weighted components
+type CompositeScorer struct {
+	name       string
+	components []WeightedComponent
+}
+
+// NewCompositeScorer creates a scorer from weighted components
+func NewCompositeScorer(name string, components ...WeightedComponent) *CompositeScorer {
+	return &CompositeScorer{name: name, components: components}
+}
+
+func (s *CompositeScorer) Name() string {
+	return s.name
+}
+
+func (s *CompositeScorer) Score(perfume *models.Perfume, query ScoringQuery) ScoreResult {
+	result := ScoreResult{Breakdown: make(map[string]float64, len(s.components))}
+	seen := make(map[string]bool)
+	for _, wc := range s.components {
+		component := wc.Component.Score(perfume, query)
+		value := component.Value * wc.Weight
+		result.Breakdown[wc.Component.Name()] += value
+		result.Total += value
+		for _, tag := range component.MatchedTags {
+			if !seen[tag] {
+				seen[tag] = true
+				result.MatchedTags = append(result.MatchedTags, tag)
+			}
+		}
+	}
+	return result
+}
+
+// BaseScoreComponent gives every perfume the same starting score
+type BaseScoreComponent struct{}
+
+func (BaseScoreComponent) Name() string { return "base" }
+
+func (BaseScoreComponent) Score(perfume *models.Perfume, query ScoringQuery) ComponentScore {
+	return ComponentScore{Value: 1}
+}
+
+// TagMatchComponent adds the accord strength (0-1) of every requested tag the perfume carries
+type TagMatchComponent struct{}
+
+func (TagMatchComponent) Name() string { return "tag_match" }
+
+func (TagMatchComponent) Score(perfume *models.Perfume, query ScoringQuery) ComponentScore {
+	var score ComponentScore
+	strengths := perfume.AccordStrengths()
+	for _, aroma := range perfume.AromaTags {
+		if query.Wants(aroma.Slug) {
+			score.Value += float64(strengths[aroma.ID]) / models.MaxAccordStrength
+			score.MatchedTags = append(score.MatchedTags, aroma.Slug)
+		}
+	}
+	return score
+}
+
+// TagCoverageComponent is the share of requested tags the perfume carries, weighted by accord strength
+type TagCoverageComponent struct{}
+
+func (TagCoverageComponent) Name() string { return "tag_coverage" }
+
+func (TagCoverageComponent) Score(perfume *models.Perfume, query ScoringQuery) ComponentScore {
+	if len(query.aromaSet) == 0 {
+		return ComponentScore{}
+	}
+	matched := TagMatchComponent{}.Score(perfume, query)
+	matched.Value /= float64(len(query.aromaSet))
+	return matched
+}
+
+// TagFocusComponent rewards perfumes whose accords are mostly the requested ones
+type TagFocusComponent struct{}
+
+func (TagFocusComponent) Name() string { return "tag_focus" }
+
+func (TagFocusComponent) Score(perfume *models.Perfume, query ScoringQuery) ComponentScore {
+	if len(perfume.AromaTags) == 0 {
+		return ComponentScore{}
+	}
+	matched := 0
+	for _, aroma := range perfume.AromaTags {
+		if query.Wants(aroma.Slug) {
+			matched++
+		}
+	}
+	return ComponentScore{Value: float64(matched) / float64(len(perfume.AromaTags))}
+}
+
+// NewDefaultScorer reproduces the original scoring: 0.5 base plus 0.3 per matched tag
+func NewDefaultScorer() Scorer {
+	return NewCompositeScorer(DefaultScoringStrategy,
+		WeightedComponent{Component: BaseScoreComponent{}, Weight: 0.5},
+		WeightedComponent{Component: TagMatchComponent{}, Weight: 0.3},
+	)
+}
+
+// NewCoverageScorer favours perfumes that cover all requested tags without many unrelated accords
+func NewCoverageScorer() Scorer {
+	return NewCompositeScorer("coverage",
+		WeightedComponent{Component: TagCoverageComponent{}, Weight: 0.7},
+		WeightedComponent{Component: TagFocusComponent{}, Weight: 0.3},
+	)
+}
+
+// ScorerRegistry holds the scoring strategies selectable by name
+type ScorerRegistry struct {
+	scorers map[string]Scorer
+}
+
+// NewScorerRegistry creates a registry with the built-in strategies
+func NewScorerRegistry() *ScorerRegistry {
+	registry := &ScorerRegistry{scorers: make(map[string]Scorer)}
+	registry.Register(NewDefaultScorer())
+	registry.Register(NewCoverageScorer())
+	return registry
+}
+
+// Register adds or replaces a strategy
+func (r *ScorerRegistry) Register(scorer Scorer) {
+	r.scorers[scorer.Name()] = scorer
+}
+
+// Get returns the named strategy, or the default one when name is empty
+func (r *ScorerRegistry) Get(name string) (Scorer, error) {
+	if name == "" {
+		name = DefaultScoringStrategy
+	}
+	scorer, ok := r.scorers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, name)
+	}
+	return scorer, nil
+}
+
+// Names lists the registered strategies
+func (r *ScorerRegistry) Names() []string {
+	names := make([]string, 0, len(r.scorers))
+	for name := range r.scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ScoredPerfume is a perfume with its score, used while ranking
+type ScoredPerfume struct {
+	Perfume *models.Perfume
+	Result  ScoreResult
+}
+
+// scoredHeap is a min-heap on score so the weakest of the current top k sits at the root
+type scoredHeap []ScoredPerfume
+
+func (h scoredHeap) Len() int           { return len(h) }
+func (h scoredHeap) Less(i, j int) bool { return rankedBefore(h[j], h[i]) }
+func (h scoredHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *scoredHeap) Push(x any)        { *h = append(*h, x.(ScoredPerfume)) }
+func (h *scoredHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// rankedBefore orders by score, then by ID so ties are deterministic
+func rankedBefore(a, b ScoredPerfume) bool {
+	if a.Result.Total != b.Result.Total {
+		return a.Result.Total > b.Result.Total
+	}
+	return a.Perfume.ID < b.Perfume.ID
+}
+
+// TopK scores every perfume and keeps the k best in O(n log k)
+func TopK(perfumes []models.Perfume, scorer Scorer, query ScoringQuery, k int) []ScoredPerfume {
+	if k <= 0 {
+		return nil
+	}
+
+	h := make(scoredHeap, 0, k+1)
+	for i := range perfumes {
+		candidate := ScoredPerfume{Perfume: &perfumes[i], Result: scorer.Score(&perfumes[i], query)}
+		if h.Len() < k {
+			heap.Push(&h, candidate)
+			continue
+		}
+		if rankedBefore(candidate, h[0]) {
+			h[0] = candidate
+			heap.Fix(&h, 0)
+		}
+	}
+
+	ranked := make([]ScoredPerfume, h.Len())
+	for i := len(ranked) - 1; i >= 0; i-- {
+		ranked[i] = heap.Pop(&h).(ScoredPerfume)
+	}
+	return ranked
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"perfume-website/internal/models"
+	"perfume-website/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRecommendationNotFound is returned when a recommendation record ID does not exist
+	ErrRecommendationNotFound = errors.New("recommendation not found")
+	// ErrInvalidRecommendationEvent is returned for an unknown event type or a perfume the record did not recommend
+	ErrInvalidRecommendationEvent = errors.New("invalid recommendation event")
+	// ErrInvalidFeedback is returned for an unknown feedback type, a bad rating or a perfume the record did not recommend
+	ErrInvalidFeedback = errors.New("invalid recommendation feedback")
+)
+
+const maxFeedbackLength = 1000
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// RecommendationHistoryService stores every recommendation set served, with the
+// impressions and clicks recorded against it
+type RecommendationHistoryService struct {
+	repo     repositories.RecommendationHistoryRepository
+	settings *RecommendationSettingsService
+}
+
+func NewRecommendationHistoryService(repo repositories.RecommendationHistoryRepository, settings *RecommendationSettingsService) *RecommendationHistoryService {
+	return &RecommendationHistoryService{repo: repo, settings: settings}
+}
+
+// RecordQuiz stores a quiz recommendation response served to the session. req
+// is the request as the client sent it, before feedback and experiments
+// changed it, so identical requests hash alike. Nothing is stored without a
+// session, since only the session can read the record or react to it.
+func (s *RecommendationHistoryService) RecordQuiz(sessionID string, req models.AdvancedRecommendationRequest, response *models.AdvancedRecommendationResponse, assignment *VariantAssignment) (*models.RecommendationRecord, error) {
+	if sessionID == "" {
+		return nil, nil
+	}
+	version := fmt.Sprintf("%s; settings v%d", response.RecommendationLogic.Version, s.settings.Get().Version)
+	preferences := req.QuizPreferences
+	record := &models.RecommendationRecord{
+		SessionID:        sessionID,
+		Endpoint:         models.RecommendationEndpointQuiz,
+		AlgorithmVersion: withExperiment(version, assignment),
+		QuizResponse:     &preferences,
+	}
+	for _, result := range response.Results {
+		reason := ""
+		if len(result.MatchReasons) > 0 {
+			reason = result.MatchReasons[0]
+		}
+		record.RecommendedPerfumes = append(record.RecommendedPerfumes, models.PerfumeScore{
+			Rank:        result.Rank,
+			PerfumeID:   result.Perfume.ID,
+			Score:       result.OverallScore,
+			MatchReason: reason,
+			Confidence:  result.Confidence,
+		})
+	}
+	if err := s.create(record, req); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// RecordRecommend stores an aroma recommendation response served to the
+// session, like RecordQuiz
+func (s *RecommendationHistoryService) RecordRecommend(sessionID string, req models.RecommendationRequest, response *models.RecommendationResponse, assignment *VariantAssignment) (*models.RecommendationRecord, error) {
+	if sessionID == "" {
+		return nil, nil
+	}
+	record := &models.RecommendationRecord{
+		SessionID:        sessionID,
+		Endpoint:         models.RecommendationEndpointRecommend,
+		AlgorithmVersion: withExperiment("scorer "+response.Strategy, assignment),
+	}
+	for i, result := range response.Results {
+		record.RecommendedPerfumes = append(record.RecommendedPerfumes, models.PerfumeScore{
+			Rank:      i + 1,
+			PerfumeID: result.Perfume.ID,
+			Score:     result.Score,
+		})
+	}
+	if err := s.create(record, req); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s *RecommendationHistoryService) create(record *models.RecommendationRecord, req any) error {
+	hash, err := requestHash(req)
+	if err != nil {
+		return err
+	}
+	record.RequestHash = hash
+	record.Timestamp = time.Now()
+	if err := s.repo.Create(record); err != nil {
+		return fmt.Errorf("failed to save recommendation history: %w", err)
+	}
+	return nil
+}
+
+// requestHash identifies identical requests, so repeats can be grouped
+func requestHash(req any) (string, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash recommendation request: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func withExperiment(version string, assignment *VariantAssignment) string {
+	if assignment == nil {
+		return version
+	}
+	return fmt.Sprintf("%s; experiment %s/%s", version, assignment.Experiment.Key, assignment.Variant.Name)
+}
+
+// RecordEvent stores an impression or click on a perfume of a record served
+// to the session
+func (s *RecommendationHistoryService) RecordEvent(sessionID string, recordID uint, eventType string, perfumeID uint) error {
+	if eventType != models.RecommendationEventImpression && eventType != models.RecommendationEventClick {
+		return fmt.Errorf("%w: type must be '%s' or '%s'", ErrInvalidRecommendationEvent, models.RecommendationEventImpression, models.RecommendationEventClick)
+	}
+	record, err := s.getForSession(sessionID, recordID)
+	if err != nil {
+		return err
+	}
+	if !recommends(record, perfumeID) {
+		return fmt.Errorf("%w: perfume %d was not recommended in %d", ErrInvalidRecommendationEvent, perfumeID, recordID)
+	}
+
+	event := &models.RecommendationEvent{RecordID: recordID, PerfumeID: perfumeID, Type: eventType}
+	if err := s.repo.CreateEvent(event); err != nil {
+		return fmt.Errorf("failed to save recommendation event: %w", err)
+	}
+	return nil
+}
+
+// getForSession returns a record only to the session it was served to. Other
+// sessions get ErrRecommendationNotFound, so record IDs cannot be probed.
+func (s *RecommendationHistoryService) getForSession(sessionID string, recordID uint) (*models.RecommendationRecord, error) {
+	record, err := s.Get(recordID)
+	if err != nil {
+		return nil, err
+	}
+	if sessionID == "" || record.SessionID != sessionID {
+		return nil, ErrRecommendationNotFound
+	}
+	return record, nil
+}
+
+func recommends(record *models.RecommendationRecord, perfumeID uint) bool {
+	return slices.ContainsFunc(record.RecommendedPerfumes, func(item models.PerfumeScore) bool { return item.PerfumeID == perfumeID })
+}
+
+// RecordFeedback stores a reaction to one perfume of a record served to the
+// session. It is applied to the session's later quiz requests.
+func (s *RecommendationHistoryService) RecordFeedback(sessionID string, recordID uint, req models.RecommendationFeedbackRequest) (*models.FeedbackRecord, error) {
+	switch req.Type {
+	case models.FeedbackThumbsUp, models.FeedbackThumbsDown, models.FeedbackNotInterested, models.FeedbackPurchased:
+	default:
+		return nil, fmt.Errorf("%w: type must be one of %s, %s, %s or %s", ErrInvalidFeedback,
+			models.FeedbackThumbsUp, models.FeedbackThumbsDown, models.FeedbackNotInterested, models.FeedbackPurchased)
+	}
+	if req.Rating < 0 || req.Rating > 5 {
+		return nil, fmt.Errorf("%w: rating must be between 1 and 5 when given", ErrInvalidFeedback)
+	}
+	if len(req.Feedback) > maxFeedbackLength {
+		return nil, fmt.Errorf("%w: feedback must be at most %d characters", ErrInvalidFeedback, maxFeedbackLength)
+	}
+
+	record, err := s.getForSession(sessionID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if !recommends(record, req.PerfumeID) {
+		return nil, fmt.Errorf("%w: perfume %d was not recommended in %d", ErrInvalidFeedback, req.PerfumeID, recordID)
+	}
+
+	feedback := &models.FeedbackRecord{
+		RecordID:  recordID,
+		SessionID: record.SessionID,
+		PerfumeID: req.PerfumeID,
+		Type:      req.Type,
+		Rating:    req.Rating,
+		Feedback:  req.Feedback,
+		Purchased: req.Type == models.FeedbackPurchased,
+		Timestamp: time.Now(),
+	}
+	if err := s.repo.CreateFeedback(feedback); err != nil {
+		return nil, fmt.Errorf("failed to save recommendation feedback: %w", err)
+	}
+	return feedback, nil
+}
+
+// ApplyFeedback adds the session's feedback to a quiz request: perfumes liked
+// or purchased seed the community signal, thumbs down demotes a perfume and
+// "not interested" excludes it. Purchased perfumes are no longer recommended,
+// liked ones still can be. The latest feedback on a perfume wins. It returns
+// nil when the session has no feedback.
+func (s *RecommendationHistoryService) ApplyFeedback(sessionID string, req *models.AdvancedRecommendationRequest) (*models.AppliedFeedback, error) {
+	if sessionID == "" {
+		return nil, nil
+	}
+	feedback, err := s.repo.GetFeedbackBySession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recommendation feedback: %w", err)
+	}
+	if len(feedback) == 0 {
+		return nil, nil
+	}
+
+	latest := make(map[uint]string)
+	var order []uint
+	for _, entry := range feedback {
+		if _, ok := latest[entry.PerfumeID]; !ok {
+			order = append(order, entry.PerfumeID)
+		}
+		latest[entry.PerfumeID] = entry.Type
+	}
+
+	applied := &models.AppliedFeedback{}
+	for _, perfumeID := range order {
+		switch latest[perfumeID] {
+		case models.FeedbackThumbsUp:
+			applied.Liked = append(applied.Liked, perfumeID)
+			if !slices.Contains(req.UpvotedPerfumeIDs, perfumeID) {
+				req.UpvotedPerfumeIDs = append(req.UpvotedPerfumeIDs, perfumeID)
+			}
+		case models.FeedbackPurchased:
+			applied.Purchased = append(applied.Purchased, perfumeID)
+			if !slices.Contains(req.LikedPerfumeIDs, perfumeID) {
+				req.LikedPerfumeIDs = append(req.LikedPerfumeIDs, perfumeID)
+			}
+		case models.FeedbackThumbsDown:
+			applied.Demoted = append(applied.Demoted, perfumeID)
+			if !slices.Contains(req.DislikedPerfumeIDs, perfumeID) {
+				req.DislikedPerfumeIDs = append(req.DislikedPerfumeIDs, perfumeID)
+			}
+		case models.FeedbackNotInterested:
+			applied.Excluded = append(applied.Excluded, perfumeID)
+			if !slices.Contains(req.ExcludeIDs, perfumeID) {
+				req.ExcludeIDs = append(req.ExcludeIDs, perfumeID)
+			}
+		}
+	}
+	return applied, nil
+}
+
+// Get returns one record with its ranked perfumes
+func (s *RecommendationHistoryService) Get(recordID uint) (*models.RecommendationRecord, error) {
+	record, err := s.repo.GetByID(recordID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecommendationNotFound
+	}
+	return record, err
+}
+
+// History returns the session's most recent records, marking the perfumes
+// that were seen or clicked
+func (s *RecommendationHistoryService) History(sessionID string, limit int) ([]models.RecommendationRecord, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	limit = min(limit, maxHistoryLimit)
+
+	records, err := s.repo.GetBySession(sessionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+	events, err := s.repo.GetEvents(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct{ record, perfume uint }
+	seen := make(map[key]bool)
+	clicked := make(map[key]bool)
+	for _, event := range events {
+		k := key{event.RecordID, event.PerfumeID}
+		switch event.Type {
+		case models.RecommendationEventImpression:
+			seen[k] = true
+		case models.RecommendationEventClick:
+			// A click implies the perfume was seen
+			seen[k], clicked[k] = true, true
+		}
+	}
+	for i := range records {
+		for j := range records[i].RecommendedPerfumes {
+			item := &records[i].RecommendedPerfumes[j]
+			k := key{records[i].ID, item.PerfumeID}
+			item.Seen, item.Clicked = seen[k], clicked[k]
+		}
+	}
+	return records, nil
+}
+
+// UnclickedPerfumes lists perfumes recommended at least minRecommended times that were never clicked
+func (s *RecommendationHistoryService) UnclickedPerfumes(minRecommended, limit int) ([]models.UnclickedPerfume, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	return s.repo.GetUnclickedPerfumes(max(minRecommended, 1), min(limit, maxHistoryLimit))
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"perfume-website/internal/models"
+	"perfume-website/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidSettings is returned when recommendation settings fail validation
+var ErrInvalidSettings = errors.New("invalid recommendation settings")
+
+// RecommendationSettingsService keeps the active recommender settings in memory
+// and swaps them atomically when an admin saves a new revision
+type RecommendationSettingsService struct {
+	repo    repositories.RecommendationSettingsRepository
+	current atomic.Pointer[models.RecommendationSettingsResponse]
+}
+
+// NewRecommendationSettingsService loads the newest saved settings, falling back to the defaults
+func NewRecommendationSettingsService(repo repositories.RecommendationSettingsRepository) *RecommendationSettingsService {
+	s := &RecommendationSettingsService{repo: repo}
+	s.current.Store(&models.RecommendationSettingsResponse{RecommendationSettings: models.DefaultRecommendationSettings()})
+	if err := s.Reload(); err != nil {
+		log.Printf("Using default recommendation settings: %v", err)
+	}
+	return s
+}
+
+// Current returns the settings to use for a single recommendation request
+func (s *RecommendationSettingsService) Current() models.RecommendationSettings {
+	return s.current.Load().RecommendationSettings
+}
+
+// Get returns the active settings together with their revision metadata
+func (s *RecommendationSettingsService) Get() models.RecommendationSettingsResponse {
+	return *s.current.Load()
+}
+
+// Start reloads the settings on a fixed interval until the returned stop
+// function is called. Update applies a change at once on the server that
+// saved it; this picks it up on the other servers sharing the database.
+func (s *RecommendationSettingsService) Start(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Reload(); err != nil {
+					log.Printf("Failed to reload recommendation settings: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// Reload re-reads the newest revision from the database
+func (s *RecommendationSettingsService) Reload() error {
+	record, err := s.repo.GetLatest()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to load recommendation settings: %w", err)
+	}
+
+	// Decode on top of the defaults so settings added after a revision was saved keep their default value
+	settings := models.DefaultRecommendationSettings()
+	if err := json.Unmarshal([]byte(record.Payload), &settings); err != nil {
+		return fmt.Errorf("failed to decode recommendation settings %d: %w", record.ID, err)
+	}
+	if err := ValidateRecommendationSettings(settings); err != nil {
+		return err
+	}
+
+	s.activate(&models.RecommendationSettingsResponse{
+		RecommendationSettings: settings,
+		Version:                record.ID,
+		UpdatedBy:              record.UpdatedBy,
+		UpdatedAt:              record.CreatedAt,
+	})
+	return nil
+}
+
+// Update validates and saves a new revision, then makes it active immediately
+func (s *RecommendationSettingsService) Update(settings models.RecommendationSettings, updatedBy string) (*models.RecommendationSettingsResponse, error) {
+	if err := ValidateRecommendationSettings(settings); err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode recommendation settings: %w", err)
+	}
+
+	record := &models.RecommendationSettingsRecord{
+		Payload:   string(payload),
+		UpdatedBy: updatedBy,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.Create(record); err != nil {
+		return nil, fmt.Errorf("failed to save recommendation settings: %w", err)
+	}
+
+	response := &models.RecommendationSettingsResponse{
+		RecommendationSettings: settings,
+		Version:                record.ID,
+		UpdatedBy:              record.UpdatedBy,
+		UpdatedAt:              record.CreatedAt,
+	}
+	s.activate(response)
+	return response, nil
+}
+
+// activate makes the settings active unless a newer revision already is, so
+// a background Reload that read the database before an Update saved cannot
+// roll the Update back
+func (s *RecommendationSettingsService) activate(next *models.RecommendationSettingsResponse) {
+	for {
+		current := s.current.Load()
+		if next.Version < current.Version || s.current.CompareAndSwap(current, next) {
+			return
+		}
+	}
+}
+
+// ValidateRecommendationSettings checks that weights and thresholds are usable
+func ValidateRecommendationSettings(settings models.RecommendationSettings) error {
+	weights := settings.Weights.AsMap()
+	total := 0.0
+	for name, weight := range weights {
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return fmt.Errorf("%w: weight %s must be a non-negative number", ErrInvalidSettings, name)
+		}
+		total += weight
+	}
+	if total <= 0 {
+		return fmt.Errorf("%w: at least one weight must be positive", ErrInvalidSettings)
+	}
+
+	t := settings.Thresholds
+	if math.IsNaN(t.MatchReason) || t.MatchReason < 0 || t.MatchReason > 1 {
+		return fmt.Errorf("%w: thresholds.match_reason must be between 0 and 1", ErrInvalidSettings)
+	}
+	prices := map[string]float64{
+		"value_price_max":    t.ValuePriceMax,
+		"casual_price_max":   t.CasualPriceMax,
+		"special_price_min":  t.SpecialPriceMin,
+		"premium_price_min":  t.PremiumPriceMin,
+		"safe_bet_price_max": t.SafeBetPriceMax,
+	}
+	for name, price := range prices {
+		if math.IsNaN(price) || math.IsInf(price, 0) || price < 0 {
+			return fmt.Errorf("%w: thresholds.%s must be a non-negative number", ErrInvalidSettings, name)
+		}
+	}
+
+	d := settings.Diversity
+	if math.IsNaN(d.Default) || d.Default < 0 || d.Default > 1 {
+		return fmt.Errorf("%w: diversity.default must be between 0 and 1", ErrInvalidSettings)
+	}
+	similarity := map[string]float64{
+		"brand":    d.Brand,
+		"category": d.Category,
+		"aroma":    d.Aroma,
+	}
+	total = 0
+	for name, weight := range similarity {
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return fmt.Errorf("%w: diversity.%s must be a non-negative number", ErrInvalidSettings, name)
+		}
+		total += weight
+	}
+	if total <= 0 {
+		return fmt.Errorf("%w: at least one diversity weight must be positive", ErrInvalidSettings)
+	}
+
+	b := settings.Budget
+	bands := map[string]models.PriceBand{
+		"budget":   b.Budget,
+		"mid":      b.Mid,
+		"luxury":   b.Luxury,
+		"designer": b.Designer,
+	}
+	for name, band := range bands {
+		if math.IsNaN(band.Min) || math.IsNaN(band.Max) || math.IsInf(band.Min, 0) || math.IsInf(band.Max, 0) || band.Min < 0 || band.Max < 0 {
+			return fmt.Errorf("%w: budget.%s must have non-negative prices", ErrInvalidSettings, name)
+		}
+		if band.Max != 0 && band.Max < band.Min {
+			return fmt.Errorf("%w: budget.%s max must not be below its min", ErrInvalidSettings, name)
+		}
+	}
+	if math.IsNaN(b.Tolerance) || math.IsInf(b.Tolerance, 0) || b.Tolerance <= 0 {
+		return fmt.Errorf("%w: budget.tolerance must be positive", ErrInvalidSettings)
+	}
+	if b.MinFittingResults < 0 {
+		return fmt.Errorf("%w: budget.min_fitting_results must not be negative", ErrInvalidSettings)
+	}
+
+	if h := settings.Season.Hemisphere; h != HemisphereNorth && h != HemisphereSouth {
+		return fmt.Errorf("%w: season.hemisphere must be '%s' or '%s'", ErrInvalidSettings, HemisphereNorth, HemisphereSouth)
+	}
+	if c := settings.Season.Climate; c != ClimateTemperate && c != ClimateTropical {
+		return fmt.Errorf("%w: season.climate must be '%s' or '%s'", ErrInvalidSettings, ClimateTemperate, ClimateTropical)
+	}
+
+	return nil
+}
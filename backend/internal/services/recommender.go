@@ -0,0 +1,51 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"perfume-website/internal/models"
+)
+
+// Quiz recommendation algorithms a request can pick with its algorithm field
+const (
+	AlgorithmMultiFactor  = "multi_factor"
+	AlgorithmScentProfile = "scent_profile"
+	// AlgorithmGift is reported for gift mode, which is part of multi_factor
+	AlgorithmGift = "gift"
+)
+
+// QuizRecommender ranks perfumes for a quiz request
+type QuizRecommender interface {
+	Name() string
+	Recommend(req models.AdvancedRecommendationRequest, settings models.RecommendationSettings) (*models.AdvancedRecommendationResponse, error)
+}
+
+// RegisterRecommender makes an additional algorithm selectable by name
+func (s *QuizService) RegisterRecommender(recommender QuizRecommender) {
+	s.recommenders[recommender.Name()] = recommender
+}
+
+// Algorithms lists the algorithms quiz requests can pick
+func (s *QuizService) Algorithms() []string {
+	names := make([]string, 0, len(s.recommenders))
+	for name := range s.recommenders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// recommender returns the algorithm the request picked, multi_factor when it names none
+func (s *QuizService) recommender(algorithm string) (QuizRecommender, error) {
+	name := strings.ToLower(strings.TrimSpace(algorithm))
+	if name == "" {
+		name = AlgorithmMultiFactor
+	}
+	recommender, ok := s.recommenders[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: algorithm must be one of %s", ErrInvalidQuizRequest, strings.Join(s.Algorithms(), ", "))
+	}
+	return recommender, nil
+}
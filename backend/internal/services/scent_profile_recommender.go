@@ -0,0 +1,610 @@
+package services
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"perfume-website/internal/models"
+)
+
+// scentProfileWeights are the fixed component weights of the ScentProfile algorithm
+var scentProfileWeights = map[string]float64{
+	"profile_match":     0.4,
+	"season_match":      0.2,
+	"occasion_match":    0.2,
+	"performance_match": 0.1,
+	"uniqueness_bonus":  0.1,
+}
+
+// minScentProfileScore drops perfumes that match on almost nothing
+const minScentProfileScore = 0.1
+
+// scentProfileSeasons rates how well each category suits a season
+var scentProfileSeasons = map[string]map[string]float64{
+	"Woody Spicy":     {"spring": 0.7, "summer": 0.3, "fall": 1.0, "winter": 1.0},
+	"Floriental":      {"spring": 1.0, "summer": 0.8, "fall": 0.8, "winter": 0.6},
+	"Woody Aromatic":  {"spring": 0.8, "summer": 0.6, "fall": 1.0, "winter": 0.9},
+	"Amber Floral":    {"spring": 0.8, "summer": 0.4, "fall": 1.0, "winter": 1.0},
+	"Floral Fruity":   {"spring": 1.0, "summer": 1.0, "fall": 0.7, "winter": 0.3},
+	"Fresh Scent":     {"spring": 1.0, "summer": 1.0, "fall": 0.6, "winter": 0.4},
+	"Citrus Aromatic": {"spring": 1.0, "summer": 1.0, "fall": 0.5, "winter": 0.3},
+}
+
+// scentProfileOccasions rates how well each category suits an occasion
+var scentProfileOccasions = map[string]map[string]float64{
+	"Woody Spicy":     {"casual": 0.8, "formal": 0.7, "evening": 1.0, "work": 0.6},
+	"Floriental":      {"casual": 0.7, "formal": 0.9, "evening": 1.0, "work": 0.7},
+	"Woody Aromatic":  {"casual": 0.9, "formal": 0.8, "evening": 0.7, "work": 0.9},
+	"Amber Floral":    {"casual": 0.6, "formal": 1.0, "evening": 1.0, "work": 0.5},
+	"Fresh Scent":     {"casual": 1.0, "formal": 0.7, "evening": 0.5, "work": 0.9},
+	"Citrus Aromatic": {"casual": 1.0, "formal": 0.8, "evening": 0.6, "work": 0.9},
+}
+
+// scentProfileSituations maps current_situation onto the occasion columns above
+var scentProfileSituations = map[string]string{
+	"work":    "work",
+	"date":    "evening",
+	"casual":  "casual",
+	"special": "formal",
+}
+
+// ScentProfileRecommender builds a ScentProfile of trait levels and seasonal
+// and occasion weights from the quiz, then scores perfumes by category and
+// notes against it. Unlike multi_factor it does not use the tunable settings.
+type ScentProfileRecommender struct {
+	catalog *CatalogService
+}
+
+func NewScentProfileRecommender(catalog *CatalogService) *ScentProfileRecommender {
+	return &ScentProfileRecommender{catalog: catalog}
+}
+
+func (s *ScentProfileRecommender) Name() string {
+	return AlgorithmScentProfile
+}
+
+// Recommend ranks perfumes against the scent profile of the quiz answers
+func (s *ScentProfileRecommender) Recommend(req models.AdvancedRecommendationRequest, settings models.RecommendationSettings) (*models.AdvancedRecommendationResponse, error) {
+	if req.Gift != nil {
+		return nil, fmt.Errorf("%w: gift mode is only available with the %s algorithm", ErrInvalidQuizRequest, AlgorithmMultiFactor)
+	}
+	season, err := resolveSeason(req, settings.Season, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Analyze user personality and create scent profile
+	profile := s.analyzePersonality(req.QuizPreferences)
+
+	// 2. Get the eligible perfumes, applying the same exclusions as multi_factor
+	perfumes, excludedByNotes, excludedByIngredients, err := getCandidatePerfumes(s.catalog, req, scoringContext{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candidate perfumes: %w", err)
+	}
+
+	// 3. Score each perfume against the profile
+	var scores []models.AdvancedRecommendationResult
+	for _, perfume := range perfumes {
+		score := s.calculatePerfumeScore(perfume, req, season.Season, profile)
+		if score.OverallScore > minScentProfileScore {
+			scores = append(scores, score)
+		}
+	}
+
+	// 4. Sort and limit results
+	results, rest := s.sortAndLimit(scores, req.MaxResults)
+
+	// 5. Describe the profile and how it was applied
+	factors := []string{"Profile Match", "Season Suitability", "Occasion Appropriateness", "Performance Preferences", "Uniqueness Factor"}
+	return &models.AdvancedRecommendationResponse{
+		Results:             results,
+		PersonalityAnalysis: s.generatePersonalityAnalysis(profile),
+		RecommendationLogic: models.RecommendationLogic{
+			Algorithm:          AlgorithmScentProfile,
+			Version:            "ScentProfile Recommendation v1.0",
+			FactorsConsidered:  factors,
+			Weighting:          maps.Clone(scentProfileWeights),
+			ProcessDescription: "Our advanced algorithm analyzes your personality traits, scent preferences, and the specific context to find perfect fragrance matches. Each perfume is scored across multiple dimensions and ranked by overall compatibility.",
+		},
+		Tips:                  s.generatePersonalizedTips(profile, req, season.Season),
+		Alternatives:          s.generateAlternatives(rest),
+		ExcludedByNotes:       excludedByNotes,
+		ExcludedByIngredients: excludedByIngredients,
+		Season:                season,
+	}, nil
+}
+
+func (s *ScentProfileRecommender) analyzePersonality(pref models.QuizPreferences) models.ScentProfile {
+	profile := models.ScentProfile{
+		PrimaryFamilies:   make([]string, 0),
+		SecondaryFamilies: make([]string, 0),
+		PreferredNotes:    make([]string, 0),
+		AvoidedNotes:      make([]string, 0),
+	}
+
+	// Determine scent families based on preferences
+	if pref.LightFresh {
+		profile.PrimaryFamilies = append(profile.PrimaryFamilies, "Fresh Scent", "Citrus Aromatic", "Aromatic")
+		profile.PreferredNotes = append(profile.PreferredNotes, "Bergamot", "Lemon", "Neroli", "Green Tea")
+		profile.EnergyLevel = 0.8
+	}
+	if pref.WarmSpicy {
+		profile.PrimaryFamilies = append(profile.PrimaryFamilies, "Woody Spicy", "Amber Spicy", "Oriental Spicy")
+		profile.PreferredNotes = append(profile.PreferredNotes, "Black Pepper", "Cardamom", "Cinnamon", "Patchouli")
+		profile.AdventurousLevel = 0.7
+	}
+	if pref.SweetGourmand {
+		profile.PrimaryFamilies = append(profile.PrimaryFamilies, "Gourmand", "Vanilla Gourmand", "Fruity Gourmand")
+		profile.PreferredNotes = append(profile.PreferredNotes, "Vanilla", "Cacao", "Praline", "Tonka Bean")
+		profile.RomanceLevel = 0.8
+	}
+	if pref.WoodyEarthy {
+		profile.PrimaryFamilies = append(profile.PrimaryFamilies, "Woody Aromatic", "Woody", "Leather")
+		profile.PreferredNotes = append(profile.PreferredNotes, "Sandalwood", "Cedarwood", "Vetiver", "Oakmoss")
+		profile.EleganceLevel = 0.7
+	}
+	if pref.FloralRomantic {
+		profile.PrimaryFamilies = append(profile.PrimaryFamilies, "Floriental", "Floral", "Amber Floral")
+		profile.PreferredNotes = append(profile.PreferredNotes, "Rose", "Jasmine", "Tuberose", "Peony")
+		profile.RomanceLevel = 0.9
+	}
+	if pref.CitrusEnergizing {
+		profile.PrimaryFamilies = append(profile.PrimaryFamilies, "Citrus Aromatic", "Fresh Scent")
+		profile.PreferredNotes = append(profile.PreferredNotes, "Lemon", "Bergamot", "Grapefruit", "Mandarin")
+		profile.EnergyLevel = 0.9
+	}
+
+	// Calculate seasonal weights
+	profile.SpringWeight = s.calculateSeasonWeight(pref, "spring")
+	profile.SummerWeight = s.calculateSeasonWeight(pref, "summer")
+	profile.FallWeight = s.calculateSeasonWeight(pref, "fall")
+	profile.WinterWeight = s.calculateSeasonWeight(pref, "winter")
+
+	// Calculate occasion weights
+	totalOccasions := 0
+	if pref.DailyWear {
+		totalOccasions++
+		profile.CasualWeight += 0.8
+	}
+	if pref.SpecialEvents {
+		totalOccasions++
+		profile.FormalWeight += 0.9
+	}
+	if pref.NightOut {
+		totalOccasions++
+		profile.EveningWeight += 0.9
+	}
+	if pref.Work {
+		totalOccasions++
+		profile.ProfessionalWeight += 0.7
+	}
+	if pref.Dates {
+		totalOccasions++
+		profile.EveningWeight += 0.8
+		profile.RomanceLevel += 0.2
+	}
+
+	if totalOccasions > 0 {
+		profile.CasualWeight /= float64(totalOccasions)
+		profile.FormalWeight /= float64(totalOccasions)
+		profile.EveningWeight /= float64(totalOccasions)
+		profile.ProfessionalWeight /= float64(totalOccasions)
+	}
+
+	// Personality trait normalization
+	if profile.AdventurousLevel == 0 {
+		profile.AdventurousLevel = 0.5
+	}
+	if profile.EleganceLevel == 0 {
+		profile.EleganceLevel = 0.5
+	}
+	if profile.EnergyLevel == 0 {
+		profile.EnergyLevel = 0.5
+	}
+	if profile.RomanceLevel == 0 {
+		profile.RomanceLevel = 0.5
+	}
+	profile.RomanceLevel = math.Min(profile.RomanceLevel, 1.0)
+
+	return profile
+}
+
+func (s *ScentProfileRecommender) calculateSeasonWeight(pref models.QuizPreferences, season string) float64 {
+	if pref.YearRound {
+		return 0.25 // Equal weight for all seasons
+	}
+
+	// Each season's own answer counts most, then its neighbours
+	order := map[string][]bool{
+		"spring": {pref.Spring, pref.Summer, pref.Fall, pref.Winter},
+		"summer": {pref.Summer, pref.Spring, pref.Fall, pref.Winter},
+		"fall":   {pref.Fall, pref.Winter, pref.Spring, pref.Summer},
+		"winter": {pref.Winter, pref.Fall, pref.Spring, pref.Summer},
+	}
+	weights := map[string][]float64{
+		"spring": {0.8, 0.6, 0.4, 0.2},
+		"summer": {0.8, 0.6, 0.3, 0.1},
+		"fall":   {0.8, 0.6, 0.4, 0.2},
+		"winter": {0.8, 0.6, 0.3, 0.1},
+	}
+	for i, chosen := range order[season] {
+		if chosen {
+			return weights[season][i]
+		}
+	}
+
+	return 0.25 // Default weight
+}
+
+func (s *ScentProfileRecommender) calculatePerfumeScore(perfume models.Perfume, req models.AdvancedRecommendationRequest, season string, profile models.ScentProfile) models.AdvancedRecommendationResult {
+	score := models.AdvancedRecommendationResult{
+		Perfume:      perfume,
+		Explanations: []models.Explanation{},
+	}
+
+	score.ProfileMatch = s.calculateProfileMatch(perfume, profile)
+	score.SeasonMatch = s.calculateSeasonMatch(perfume, season)
+	score.OccasionMatch = s.calculateOccasionMatch(perfume, req.CurrentSituation)
+	score.PerformanceMatch = s.calculatePerformanceMatch(perfume, req.QuizPreferences)
+	score.UniquenessBonus = s.calculateUniquenessBonus(perfume, profile)
+
+	// Calculate weighted overall score
+	score.ScoreBreakdown = map[string]float64{
+		"profile_match":     roundTo2(score.ProfileMatch * scentProfileWeights["profile_match"]),
+		"season_match":      roundTo2(score.SeasonMatch * scentProfileWeights["season_match"]),
+		"occasion_match":    roundTo2(score.OccasionMatch * scentProfileWeights["occasion_match"]),
+		"performance_match": roundTo2(score.PerformanceMatch * scentProfileWeights["performance_match"]),
+		"uniqueness_bonus":  roundTo2(score.UniquenessBonus * scentProfileWeights["uniqueness_bonus"]),
+	}
+	score.OverallScore = score.ProfileMatch*scentProfileWeights["profile_match"] +
+		score.SeasonMatch*scentProfileWeights["season_match"] +
+		score.OccasionMatch*scentProfileWeights["occasion_match"] +
+		score.PerformanceMatch*scentProfileWeights["performance_match"] +
+		score.UniquenessBonus*scentProfileWeights["uniqueness_bonus"]
+
+	// Generate match reasons and warnings
+	score.MatchReasons, score.Warnings = s.generateMatchReasons(perfume, score)
+	score.BestFor = s.generateBestFor(profile)
+	score.WearTiming = s.generateWearTiming(perfume, req)
+	score.Longevity = s.mapLongevityToText(perfume.Longevity)
+	score.Projection = s.mapProjectionToText(perfume.Sillage)
+
+	// Calculate confidence based on score variance
+	score.Confidence = s.calculateConfidence(score)
+
+	return score
+}
+
+func (s *ScentProfileRecommender) calculateProfileMatch(perfume models.Perfume, profile models.ScentProfile) float64 {
+	match := 0.0
+	category := strings.ToLower(perfume.Category)
+
+	// Check if perfume category matches primary families
+	for _, family := range profile.PrimaryFamilies {
+		if strings.Contains(category, strings.ToLower(family)) {
+			match += 0.5
+		}
+	}
+
+	// Check secondary families
+	for _, family := range profile.SecondaryFamilies {
+		if strings.Contains(category, strings.ToLower(family)) {
+			match += 0.3
+		}
+	}
+
+	// Check preferred notes in perfume notes
+	for _, note := range perfume.Notes {
+		for _, preferredNote := range profile.PreferredNotes {
+			if strings.Contains(strings.ToLower(note.NoteName), strings.ToLower(preferredNote)) {
+				match += 0.2
+			}
+		}
+	}
+
+	return math.Min(match, 1.0)
+}
+
+func (s *ScentProfileRecommender) calculateSeasonMatch(perfume models.Perfume, season string) float64 {
+	if suitability, ok := scentProfileSeasons[perfume.Category][season]; ok {
+		return suitability
+	}
+	return 0.5 // Default moderate suitability, also for the tropical wet and dry seasons
+}
+
+func (s *ScentProfileRecommender) calculateOccasionMatch(perfume models.Perfume, situation string) float64 {
+	occasion := scentProfileSituations[situation]
+	if suitability, ok := scentProfileOccasions[perfume.Category][occasion]; ok {
+		return suitability
+	}
+	return 0.5 // Default moderate suitability
+}
+
+func (s *ScentProfileRecommender) calculatePerformanceMatch(perfume models.Perfume, pref models.QuizPreferences) float64 {
+	match := 0.5 // Base score
+
+	// Longevity preference matching
+	if preferred, ok := performanceLevel(pref.Longevity); ok {
+		if actual, known := performanceLevel(perfume.Longevity); known {
+			diff := math.Abs(float64(actual - preferred))
+			match += (1.0 - diff/80.0) * 0.5 // Normalize to 0-1
+		}
+	}
+
+	// Sillage preference matching
+	if preferred, ok := performanceLevel(pref.Sillage); ok {
+		if actual, known := performanceLevel(perfume.Sillage); known {
+			diff := math.Abs(float64(actual - preferred))
+			match += (1.0 - diff/80.0) * 0.5 // Normalize to 0-1
+		}
+	}
+
+	return math.Min(match, 1.0)
+}
+
+func (s *ScentProfileRecommender) calculateUniquenessBonus(perfume models.Perfume, profile models.ScentProfile) float64 {
+	bonus := 0.0
+
+	// Bonus for unique categories
+	uniqueCategories := []string{"Oud", "Leather", "Incense", "Chypre", "Fougere"}
+	for _, unique := range uniqueCategories {
+		if strings.Contains(strings.ToLower(perfume.Category), strings.ToLower(unique)) {
+			bonus += 0.3
+		}
+	}
+
+	// Adventure bonus for less common categories
+	if profile.AdventurousLevel > 0.7 && perfume.Category != "Floriental" && perfume.Category != "Woody Spicy" {
+		bonus += 0.2
+	}
+
+	return math.Min(bonus, 1.0)
+}
+
+func (s *ScentProfileRecommender) generateMatchReasons(perfume models.Perfume, score models.AdvancedRecommendationResult) ([]string, []string) {
+	reasons := make([]string, 0)
+	warnings := make([]string, 0)
+
+	// High score reasons
+	if score.ProfileMatch > 0.8 {
+		reasons = append(reasons, "Perfect match for your scent preferences")
+	}
+	if score.SeasonMatch > 0.8 {
+		reasons = append(reasons, "Ideal for current season")
+	}
+	if score.OccasionMatch > 0.8 {
+		reasons = append(reasons, "Perfect for the occasion")
+	}
+	if score.PerformanceMatch > 0.8 {
+		reasons = append(reasons, "Matches your performance preferences")
+	}
+	if score.UniquenessBonus > 0.5 {
+		reasons = append(reasons, "Unique and distinctive scent")
+	}
+
+	// Specific category-based reasons
+	switch perfume.Category {
+	case "Woody Spicy":
+		reasons = append(reasons, "Warm and sophisticated - perfect for confident personalities")
+	case "Floriental":
+		reasons = append(reasons, "Romantic and elegant - timeless feminine appeal")
+	case "Fresh Scent":
+		reasons = append(reasons, "Clean and refreshing - perfect for daily wear")
+	case "Citrus Aromatic":
+		reasons = append(reasons, "Energizing and uplifting - boosts your confidence")
+	}
+
+	// Warnings
+	if score.ProfileMatch < 0.3 {
+		warnings = append(warnings, "May not align with your usual preferences")
+	}
+	if score.SeasonMatch < 0.3 {
+		warnings = append(warnings, "Better suited for a different season")
+	}
+	if level, ok := performanceLevel(perfume.Sillage); ok && level > 70 {
+		warnings = append(warnings, "Strong projection - may be overwhelming in close spaces")
+	}
+
+	return reasons, warnings
+}
+
+func (s *ScentProfileRecommender) generateBestFor(profile models.ScentProfile) []string {
+	bestFor := make([]string, 0)
+
+	if profile.EveningWeight > 0.7 {
+		bestFor = append(bestFor, "Evening Wear")
+	}
+	if profile.CasualWeight > 0.7 {
+		bestFor = append(bestFor, "Casual Outings")
+	}
+	if profile.FormalWeight > 0.7 {
+		bestFor = append(bestFor, "Special Events")
+	}
+	if profile.ProfessionalWeight > 0.7 {
+		bestFor = append(bestFor, "Work Environment")
+	}
+
+	if profile.RomanceLevel > 0.7 {
+		bestFor = append(bestFor, "Date Nights")
+	}
+	if profile.EnergyLevel > 0.7 {
+		bestFor = append(bestFor, "Daytime Activities")
+	}
+
+	return bestFor
+}
+
+func (s *ScentProfileRecommender) generateWearTiming(perfume models.Perfume, req models.AdvancedRecommendationRequest) []string {
+	timing := make([]string, 0)
+
+	// Based on occasion
+	switch req.CurrentSituation {
+	case "work":
+		timing = append(timing, "Morning application", "Lasts through workday")
+	case "date":
+		timing = append(timing, "Apply 30 mins before", "Perfect for evening")
+	case "casual":
+		timing = append(timing, "Any time of day", "Versatile wear")
+	case "special":
+		timing = append(timing, "Apply 1 hour before event", "Long-lasting for special moments")
+	}
+
+	// Based on longevity
+	if level, ok := performanceLevel(perfume.Longevity); ok {
+		if level > 70 {
+			timing = append(timing, "Single application lasts all day")
+		} else if level < 50 {
+			timing = append(timing, "Reapply every 4-6 hours")
+		}
+	}
+
+	return timing
+}
+
+func (s *ScentProfileRecommender) calculateConfidence(score models.AdvancedRecommendationResult) float64 {
+	// Calculate variance in scores
+	scores := []float64{score.ProfileMatch, score.SeasonMatch, score.OccasionMatch, score.PerformanceMatch}
+	mean := (scores[0] + scores[1] + scores[2] + scores[3]) / 4.0
+
+	variance := 0.0
+	for _, value := range scores {
+		variance += math.Pow(value-mean, 2)
+	}
+	variance /= 4.0
+	stdDev := math.Sqrt(variance)
+
+	// Higher confidence for consistent scores
+	confidence := 1.0 - (stdDev * 0.5)
+
+	// Boost confidence based on overall score
+	if score.OverallScore > 0.8 {
+		confidence *= 1.1
+	}
+
+	return math.Min(confidence, 1.0)
+}
+
+// sortAndLimit ranks the scored perfumes and returns the top maxResults,
+// followed by the ones that did not make it
+func (s *ScentProfileRecommender) sortAndLimit(scores []models.AdvancedRecommendationResult, maxResults int) ([]models.AdvancedRecommendationResult, []models.AdvancedRecommendationResult) {
+	// Sort by overall score, breaking ties by ID so the order is stable
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].OverallScore != scores[j].OverallScore {
+			return scores[i].OverallScore > scores[j].OverallScore
+		}
+		return scores[i].Perfume.ID < scores[j].Perfume.ID
+	})
+
+	if maxResults <= 0 {
+		maxResults = 6
+	}
+	maxResults = min(maxResults, len(scores))
+	results, rest := scores[:maxResults:maxResults], scores[maxResults:]
+	for i := range results {
+		results[i].Rank = i + 1
+		results[i].RelevanceRank = i + 1
+	}
+	if results == nil {
+		results = []models.AdvancedRecommendationResult{}
+	}
+	return results, rest
+}
+
+func (s *ScentProfileRecommender) mapLongevityToText(longevity string) string {
+	level, _ := performanceLevel(longevity)
+	if level >= 70 {
+		return "Long-lasting (8+ hours)"
+	} else if level >= 50 {
+		return "Moderate (6-8 hours)"
+	}
+	return "Light (4-6 hours)"
+}
+
+func (s *ScentProfileRecommender) mapProjectionToText(sillage string) string {
+	level, _ := performanceLevel(sillage)
+	if level >= 70 {
+		return "Heavy - Noticeable from afar"
+	} else if level >= 50 {
+		return "Moderate - Noticeable in close proximity"
+	}
+	return "Subtle - Close to skin"
+}
+
+func (s *ScentProfileRecommender) generatePersonalityAnalysis(profile models.ScentProfile) models.PersonalityAnalysis {
+	personality := models.PersonalityAnalysis{
+		KeyTraits: make([]string, 0),
+	}
+
+	// Determine scent personality
+	if profile.RomanceLevel > 0.7 && profile.EleganceLevel > 0.7 {
+		personality.ScentPersonality = "The Romantic Elegant"
+		personality.KeyTraits = append(personality.KeyTraits, "Sophisticated", "Charming", "Timeless")
+		personality.StyleDescription = "You appreciate classic, romantic fragrances that exude elegance and grace. Your perfect scents are timeless and feminine."
+	} else if profile.EnergyLevel > 0.7 && profile.AdventurousLevel > 0.7 {
+		personality.ScentPersonality = "The Adventurous Explorer"
+		personality.KeyTraits = append(personality.KeyTraits, "Bold", "Energetic", "Unique")
+		personality.StyleDescription = "You love discovering new and exciting scents that make a statement. Your perfect fragrances are distinctive and memorable."
+	} else if profile.ProfessionalWeight > 0.7 && profile.EleganceLevel > 0.6 {
+		personality.ScentPersonality = "The Professional Powerhouse"
+		personality.KeyTraits = append(personality.KeyTraits, "Confident", "Polished", "Ambitious")
+		personality.StyleDescription = "You prefer sophisticated, professional scents that command respect and enhance your presence in the workplace."
+	} else if profile.CasualWeight > 0.7 && profile.EnergyLevel > 0.6 {
+		personality.ScentPersonality = "The Casual Charmer"
+		personality.KeyTraits = append(personality.KeyTraits, "Approachable", "Friendly", "Versatile")
+		personality.StyleDescription = "You love versatile, everyday fragrances that are perfect for any casual occasion and make you feel confident."
+	} else {
+		personality.ScentPersonality = "The Balanced Connoisseur"
+		personality.KeyTraits = append(personality.KeyTraits, "Thoughtful", "Versatile", "Refined")
+		personality.StyleDescription = "You appreciate a balanced approach to fragrance, selecting scents that work well across different situations and moods."
+	}
+
+	personality.RecommendationStyle = fmt.Sprintf("Based on your %s personality, we recommend fragrances that reflect your %s nature.",
+		personality.ScentPersonality, strings.Join(personality.KeyTraits, ", "))
+
+	return personality
+}
+
+func (s *ScentProfileRecommender) generatePersonalizedTips(profile models.ScentProfile, req models.AdvancedRecommendationRequest, season string) []string {
+	tips := make([]string, 0)
+
+	// Seasonal tips
+	if season == "summer" {
+		tips = append(tips, "Apply fragrance to pulse points for better longevity in hot weather")
+		tips = append(tips, "Consider lighter, fresher scents during summer months")
+	}
+
+	// Longevity tips
+	if profile.EnergyLevel > 0.7 {
+		tips = append(tips, "Layer your fragrance with matching body products for longer wear")
+	}
+
+	// Occasion tips
+	if req.CurrentSituation == "work" {
+		tips = append(tips, "Apply fragrance 15-20 minutes before arriving at work")
+		tips = append(tips, "Choose subtle scents for professional environments")
+	}
+
+	// Personality-based tips
+	if profile.AdventurousLevel > 0.7 {
+		tips = append(tips, "Don't be afraid to try unique combinations and niche fragrances")
+	}
+
+	if profile.RomanceLevel > 0.7 {
+		tips = append(tips, "Save your most romantic scents for special occasions")
+	}
+
+	return tips
+}
+
+// generateAlternatives returns the best few perfumes that did not make the results
+func (s *ScentProfileRecommender) generateAlternatives(rest []models.AdvancedRecommendationResult) []models.Perfume {
+	alternatives := make([]models.Perfume, 0, 3)
+	for _, result := range rest[:min(3, len(rest))] {
+		alternatives = append(alternatives, result.Perfume)
+	}
+	return alternatives
+}
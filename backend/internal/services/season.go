@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"perfume-website/internal/models"
+)
+
+const (
+	HemisphereNorth = "north"
+	HemisphereSouth = "south"
+
+	ClimateTemperate = "temperate"
+	ClimateTropical  = "tropical"
+
+	// tropicLatitude is the latitude of the tropics of Cancer and Capricorn
+	tropicLatitude = 23.44
+)
+
+// seasonAliases maps accepted season names to the ones used in scoring
+var seasonAliases = map[string]string{
+	"spring": "spring",
+	"summer": "summer",
+	"fall":   "fall",
+	"autumn": "fall",
+	"winter": "winter",
+	"wet":    "wet",
+	"rainy":  "wet",
+	"dry":    "dry",
+}
+
+// resolveSeason returns the season to score against: the one named in the
+// request, or else the season detected from the request date and location. An
+// unknown season name is treated like an omitted one.
+func resolveSeason(req models.AdvancedRecommendationRequest, defaults models.SeasonSettings, now time.Time) (*models.SeasonInfo, error) {
+	if canonical, ok := seasonAliases[strings.ToLower(strings.TrimSpace(req.Season))]; ok {
+		return &models.SeasonInfo{Season: canonical, Source: models.SeasonSourceRequest}, nil
+	}
+
+	date := now
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%w: date must be formatted as YYYY-MM-DD", ErrInvalidQuizRequest)
+		}
+		date = parsed
+	}
+
+	hemisphere, climate, err := resolveLocation(req.Latitude, req.Hemisphere, req.Climate, defaults)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuizRequest, err)
+	}
+
+	return &models.SeasonInfo{
+		Season:     detectSeason(date.Month(), hemisphere, climate),
+		Source:     models.SeasonSourceDetected,
+		Date:       date.Format("2006-01-02"),
+		Hemisphere: hemisphere,
+		Climate:    climate,
+	}, nil
+}
+
+// resolveLocation returns the hemisphere and climate of a request: the
+// configured defaults, overridden by the latitude, then by the explicit values
+func resolveLocation(latitude *float64, hemisphere, climate string, defaults models.SeasonSettings) (string, string, error) {
+	resolvedHemisphere, resolvedClimate := defaults.Hemisphere, defaults.Climate
+	if latitude != nil {
+		if math.IsNaN(*latitude) || *latitude < -90 || *latitude > 90 {
+			return "", "", errors.New("latitude must be between -90 and 90")
+		}
+		resolvedHemisphere = HemisphereNorth
+		if *latitude < 0 {
+			resolvedHemisphere = HemisphereSouth
+		}
+		resolvedClimate = ClimateTemperate
+		if math.Abs(*latitude) <= tropicLatitude {
+			resolvedClimate = ClimateTropical
+		}
+	}
+	if hemisphere != "" {
+		resolvedHemisphere = strings.ToLower(strings.TrimSpace(hemisphere))
+		if resolvedHemisphere != HemisphereNorth && resolvedHemisphere != HemisphereSouth {
+			return "", "", fmt.Errorf("hemisphere must be '%s' or '%s'", HemisphereNorth, HemisphereSouth)
+		}
+	}
+	if climate != "" {
+		resolvedClimate = strings.ToLower(strings.TrimSpace(climate))
+		if resolvedClimate != ClimateTemperate && resolvedClimate != ClimateTropical {
+			return "", "", fmt.Errorf("climate must be '%s' or '%s'", ClimateTemperate, ClimateTropical)
+		}
+	}
+	return resolvedHemisphere, resolvedClimate, nil
+}
+
+// climateSeasons lists the seasons of a climate in calendar order
+func climateSeasons(climate string) []string {
+	if climate == ClimateTropical {
+		return []string{"wet", "dry"}
+	}
+	return []string{"spring", "summer", "fall", "winter"}
+}
+
+// detectSeason uses meteorological seasons for temperate climates. In the
+// tropics it uses the monsoon: south of the equator (most of Indonesia) the wet
+// season runs from November to March, north of it from May to October.
+func detectSeason(month time.Month, hemisphere, climate string) string {
+	if climate == ClimateTropical {
+		wet := month >= time.November || month <= time.March
+		if hemisphere == HemisphereNorth {
+			wet = month >= time.May && month <= time.October
+		}
+		if wet {
+			return "wet"
+		}
+		return "dry"
+	}
+
+	// Shift southern months by half a year so both hemispheres share one table
+	if hemisphere == HemisphereSouth {
+		month = (month+5)%12 + 1
+	}
+	switch month {
+	case time.March, time.April, time.May:
+		return "spring"
+	case time.June, time.July, time.August:
+		return "summer"
+	case time.September, time.October, time.November:
+		return "fall"
+	default:
+		return "winter"
+	}
+}
+
+// seasonLabel is how a season reads in a match reason
+func seasonLabel(season string) string {
+	switch season {
+	case "wet", "dry":
+		return "the " + season + " season"
+	default:
+		return season + " weather"
+	}
+}
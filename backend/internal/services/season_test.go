@@ -0,0 +1,109 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"perfume-website/internal/models"
+)
+
+func TestDetectSeason(t *testing.T) {
+	tests := []struct {
+		month      time.Month
+		hemisphere string
+		climate    string
+		season     string
+	}{
+		{time.January, HemisphereNorth, ClimateTemperate, "winter"},
+		{time.April, HemisphereNorth, ClimateTemperate, "spring"},
+		{time.July, HemisphereNorth, ClimateTemperate, "summer"},
+		{time.October, HemisphereNorth, ClimateTemperate, "fall"},
+		{time.December, HemisphereNorth, ClimateTemperate, "winter"},
+
+		// The southern hemisphere is half a year ahead
+		{time.January, HemisphereSouth, ClimateTemperate, "summer"},
+		{time.April, HemisphereSouth, ClimateTemperate, "fall"},
+		{time.July, HemisphereSouth, ClimateTemperate, "winter"},
+		{time.October, HemisphereSouth, ClimateTemperate, "spring"},
+		{time.December, HemisphereSouth, ClimateTemperate, "summer"},
+
+		// South of the equator the monsoon rains fall from November to March
+		{time.November, HemisphereSouth, ClimateTropical, "wet"},
+		{time.January, HemisphereSouth, ClimateTropical, "wet"},
+		{time.March, HemisphereSouth, ClimateTropical, "wet"},
+		{time.April, HemisphereSouth, ClimateTropical, "dry"},
+		{time.August, HemisphereSouth, ClimateTropical, "dry"},
+		{time.October, HemisphereSouth, ClimateTropical, "dry"},
+
+		// North of it from May to October
+		{time.April, HemisphereNorth, ClimateTropical, "dry"},
+		{time.May, HemisphereNorth, ClimateTropical, "wet"},
+		{time.October, HemisphereNorth, ClimateTropical, "wet"},
+		{time.November, HemisphereNorth, ClimateTropical, "dry"},
+		{time.January, HemisphereNorth, ClimateTropical, "dry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.month.String()+"/"+tt.hemisphere+"/"+tt.climate, func(t *testing.T) {
+			if season := detectSeason(tt.month, tt.hemisphere, tt.climate); season != tt.season {
+				t.Errorf("detectSeason = %q, want %q", season, tt.season)
+			}
+		})
+	}
+}
+
+func TestResolveSeason(t *testing.T) {
+	now := time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC)
+	defaults := models.SeasonSettings{Hemisphere: HemisphereNorth, Climate: ClimateTemperate}
+	latitude := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name   string
+		req    models.AdvancedRecommendationRequest
+		season string
+		source string
+	}{
+		{"named season", models.AdvancedRecommendationRequest{Season: "Summer"}, "summer", models.SeasonSourceRequest},
+		{"alias", models.AdvancedRecommendationRequest{Season: "autumn"}, "fall", models.SeasonSourceRequest},
+		{"unknown season is detected", models.AdvancedRecommendationRequest{Season: "monsoon"}, "winter", models.SeasonSourceDetected},
+		{"omitted season uses the defaults", models.AdvancedRecommendationRequest{}, "winter", models.SeasonSourceDetected},
+		{"request date", models.AdvancedRecommendationRequest{Date: "2026-07-01"}, "summer", models.SeasonSourceDetected},
+		{"southern hemisphere", models.AdvancedRecommendationRequest{Hemisphere: "South"}, "summer", models.SeasonSourceDetected},
+		{"southern latitude", models.AdvancedRecommendationRequest{Latitude: latitude(-33.9)}, "summer", models.SeasonSourceDetected},
+		{"southern tropical latitude", models.AdvancedRecommendationRequest{Latitude: latitude(-6.2)}, "wet", models.SeasonSourceDetected},
+		{"northern tropical latitude", models.AdvancedRecommendationRequest{Latitude: latitude(13.7)}, "dry", models.SeasonSourceDetected},
+		{"climate overrides latitude", models.AdvancedRecommendationRequest{Latitude: latitude(-6.2), Climate: "temperate"}, "summer", models.SeasonSourceDetected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := resolveSeason(tt.req, defaults, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Season != tt.season || info.Source != tt.source {
+				t.Errorf("resolveSeason = %s (%s), want %s (%s)", info.Season, info.Source, tt.season, tt.source)
+			}
+		})
+	}
+}
+
+func TestResolveSeasonRejectsInvalidLocation(t *testing.T) {
+	latitude := 91.0
+	tests := []struct {
+		name string
+		req  models.AdvancedRecommendationRequest
+	}{
+		{"date", models.AdvancedRecommendationRequest{Date: "15/01/2026"}},
+		{"latitude", models.AdvancedRecommendationRequest{Latitude: &latitude}},
+		{"hemisphere", models.AdvancedRecommendationRequest{Hemisphere: "east"}},
+		{"climate", models.AdvancedRecommendationRequest{Climate: "arctic"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolveSeason(tt.req, models.SeasonSettings{Hemisphere: HemisphereNorth, Climate: ClimateTemperate}, time.Now())
+			if !errors.Is(err, ErrInvalidQuizRequest) {
+				t.Errorf("err = %v, want ErrInvalidQuizRequest", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"perfume-website/internal/models"
+	"perfume-website/internal/repositories"
+)
+
+// ErrInvalidTranslation is returned when a translation fails validation
+var ErrInvalidTranslation = errors.New("invalid translation")
+
+// translatableFields lists which fields of each entity type can be translated
+var translatableFields = map[models.TranslationEntity][]string{
+	models.TranslationEntityAromaTag:    {models.TranslationFieldName},
+	models.TranslationEntityNote:        {models.TranslationFieldName},
+	models.TranslationEntityPerfume:     {models.TranslationFieldDescription},
+	models.TranslationEntityPersonality: {models.TranslationFieldName, models.TranslationFieldDescription},
+}
+
+type TranslationService interface {
+	DefaultLocale() string
+	ResolveLocales(lang, acceptLanguage string) []string
+	LocalizePerfume(perfume *models.Perfume, locales []string)
+	LocalizePerfumeResponse(perfume *models.PerfumeResponse, locales []string)
+	LocalizeAromaTags(tags []models.AromaTag, locales []string) []models.AromaTag
+	LocalizePersonalityAnalysis(analysis *models.PersonalityAnalysis, locales []string)
+	LocalizePersonalityTypes(types []map[string]interface{}, locales []string) []map[string]interface{}
+	UpsertTranslation(req models.UpsertTranslationRequest) (*models.Translation, error)
+	ListTranslations(entityType models.TranslationEntity, locale string) ([]models.Translation, error)
+	DeleteTranslation(id uint) error
+	GetMissingTranslations(locale string, entityType models.TranslationEntity) (*models.MissingTranslationsReport, error)
+}
+
+type translationService struct {
+	translationRepo  repositories.TranslationRepository
+	defaultLocale    string
+	supportedLocales map[string]bool
+
+	// cache holds translations per locale, keyed by translationKey
+	mu    sync.RWMutex
+	cache map[string]map[string]string
+}
+
+func NewTranslationService(translationRepo repositories.TranslationRepository, defaultLocale string, supportedLocales []string) TranslationService {
+	s := &translationService{
+		translationRepo:  translationRepo,
+		defaultLocale:    normalizeLocale(defaultLocale),
+		supportedLocales: make(map[string]bool),
+		cache:            make(map[string]map[string]string),
+	}
+	s.supportedLocales[s.defaultLocale] = true
+	for _, locale := range supportedLocales {
+		if locale = normalizeLocale(locale); locale != "" {
+			s.supportedLocales[locale] = true
+		}
+	}
+	return s
+}
+
+func (s *translationService) DefaultLocale() string {
+	return s.defaultLocale
+}
+
+// ResolveLocales builds the fallback chain for a request: the explicit lang
+// parameter first, then Accept-Language entries by quality, then the default
+// locale. Regional tags such as "id-ID" fall back to their base language.
+func (s *translationService) ResolveLocales(lang, acceptLanguage string) []string {
+	var requested []string
+	if lang != "" {
+		requested = append(requested, lang)
+	}
+	requested = append(requested, parseAcceptLanguage(acceptLanguage)...)
+
+	seen := make(map[string]bool)
+	var chain []string
+	add := func(locale string) {
+		if s.supportedLocales[locale] && !seen[locale] {
+			seen[locale] = true
+			chain = append(chain, locale)
+		}
+	}
+
+	for _, tag := range requested {
+		tag = normalizeLocale(tag)
+		add(tag)
+		if base, _, found := strings.Cut(tag, "-"); found {
+			add(base)
+		}
+	}
+	add(s.defaultLocale)
+
+	return chain
+}
+
+// LocalizePerfume replaces the description, aroma tag names and note names of
+// a perfume. Slices are copied so shared perfumes are never modified.
+func (s *translationService) LocalizePerfume(perfume *models.Perfume, locales []string) {
+	if s.isDefault(locales) {
+		return
+	}
+
+	perfume.Description = s.lookup(locales, models.TranslationEntityPerfume, perfumeKey(perfume.ID), models.TranslationFieldDescription, perfume.Description)
+	perfume.AromaTags = s.LocalizeAromaTags(perfume.AromaTags, locales)
+
+	notes := make([]models.Note, len(perfume.Notes))
+	for i, note := range perfume.Notes {
+		note.NoteName = s.lookup(locales, models.TranslationEntityNote, noteKey(note.NoteName), models.TranslationFieldName, note.NoteName)
+		notes[i] = note
+	}
+	perfume.Notes = notes
+}
+
+// LocalizePerfumeResponse applies the same translations as LocalizePerfume to an API response
+func (s *translationService) LocalizePerfumeResponse(perfume *models.PerfumeResponse, locales []string) {
+	if s.isDefault(locales) {
+		return
+	}
+
+	perfume.Description = s.lookup(locales, models.TranslationEntityPerfume, perfumeKey(perfume.ID), models.TranslationFieldDescription, perfume.Description)
+	perfume.AromaTags = s.LocalizeAromaTags(perfume.AromaTags, locales)
+
+	accords := make([]models.AccordResponse, len(perfume.Accords))
+	for i, accord := range perfume.Accords {
+		accord.Name = s.lookup(locales, models.TranslationEntityAromaTag, accord.Slug, models.TranslationFieldName, accord.Name)
+		accords[i] = accord
+	}
+	perfume.Accords = accords
+
+	notes := make([]models.Note, len(perfume.Notes))
+	for i, note := range perfume.Notes {
+		note.NoteName = s.lookup(locales, models.TranslationEntityNote, noteKey(note.NoteName), models.TranslationFieldName, note.NoteName)
+		notes[i] = note
+	}
+	perfume.Notes = notes
+}
+
+// LocalizeAromaTags returns a copy of the tags with translated names
+func (s *translationService) LocalizeAromaTags(tags []models.AromaTag, locales []string) []models.AromaTag {
+	if s.isDefault(locales) || tags == nil {
+		return tags
+	}
+
+	localized := make([]models.AromaTag, len(tags))
+	for i, tag := range tags {
+		tag.Name = s.lookup(locales, models.TranslationEntityAromaTag, tag.Slug, models.TranslationFieldName, tag.Name)
+		localized[i] = tag
+	}
+	return localized
+}
+
+// LocalizePersonalityAnalysis translates the personality name and style
+// description of a quiz result
+func (s *translationService) LocalizePersonalityAnalysis(analysis *models.PersonalityAnalysis, locales []string) {
+	if s.isDefault(locales) {
+		return
+	}
+
+	key := personalityKey(analysis.ScentPersonality)
+	analysis.ScentPersonality = s.lookup(locales, models.TranslationEntityPersonality, key, models.TranslationFieldName, analysis.ScentPersonality)
+	analysis.StyleDescription = s.lookup(locales, models.TranslationEntityPersonality, key, models.TranslationFieldDescription, analysis.StyleDescription)
+}
+
+// LocalizePersonalityTypes returns copies of the personality type listings
+// with translated names and descriptions
+func (s *translationService) LocalizePersonalityTypes(types []map[string]interface{}, locales []string) []map[string]interface{} {
+	if s.isDefault(locales) {
+		return types
+	}
+
+	localized := make([]map[string]interface{}, len(types))
+	for i, personality := range types {
+		copied := make(map[string]interface{}, len(personality))
+		for field, value := range personality {
+			copied[field] = value
+		}
+		name, _ := personality["type"].(string)
+		description, _ := personality["description"].(string)
+		key := personalityKey(name)
+		copied["type"] = s.lookup(locales, models.TranslationEntityPersonality, key, models.TranslationFieldName, name)
+		copied["description"] = s.lookup(locales, models.TranslationEntityPersonality, key, models.TranslationFieldDescription, description)
+		localized[i] = copied
+	}
+	return localized
+}
+
+func (s *translationService) UpsertTranslation(req models.UpsertTranslationRequest) (*models.Translation, error) {
+	fields, ok := translatableFields[req.EntityType]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown entity type '%s'", ErrInvalidTranslation, req.EntityType)
+	}
+	if !slices.Contains(fields, req.Field) {
+		return nil, fmt.Errorf("%w: %s translations support the fields '%s'", ErrInvalidTranslation, req.EntityType, strings.Join(fields, "', '"))
+	}
+
+	locale := normalizeLocale(req.Locale)
+	if !s.supportedLocales[locale] {
+		return nil, fmt.Errorf("%w: unsupported locale '%s'", ErrInvalidTranslation, req.Locale)
+	}
+
+	key := strings.TrimSpace(req.EntityKey)
+	switch req.EntityType {
+	case models.TranslationEntityNote:
+		key = noteKey(key)
+	case models.TranslationEntityPersonality:
+		key = personalityKey(key)
+	}
+	if key == "" {
+		return nil, fmt.Errorf("%w: entity_key is required", ErrInvalidTranslation)
+	}
+
+	translation := &models.Translation{
+		EntityType: req.EntityType,
+		EntityKey:  key,
+		Field:      req.Field,
+		Locale:     locale,
+		Value:      req.Value,
+	}
+	if err := s.translationRepo.Upsert(translation); err != nil {
+		return nil, err
+	}
+	s.invalidate(locale)
+
+	return translation, nil
+}
+
+func (s *translationService) ListTranslations(entityType models.TranslationEntity, locale string) ([]models.Translation, error) {
+	return s.translationRepo.List(entityType, normalizeLocale(locale))
+}
+
+func (s *translationService) DeleteTranslation(id uint) error {
+	translation, err := s.translationRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.translationRepo.Delete(id); err != nil {
+		return err
+	}
+	s.invalidate(translation.Locale)
+	return nil
+}
+
+// GetMissingTranslations reports catalog text that has no translation in the locale
+func (s *translationService) GetMissingTranslations(locale string, entityType models.TranslationEntity) (*models.MissingTranslationsReport, error) {
+	locale = normalizeLocale(locale)
+	if !s.supportedLocales[locale] {
+		return nil, fmt.Errorf("%w: unsupported locale '%s'", ErrInvalidTranslation, locale)
+	}
+
+	sources, err := s.translationRepo.ListSourceTexts()
+	if err != nil {
+		return nil, err
+	}
+	sources = append(sources, personalitySourceTexts()...)
+	translations, err := s.loadLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	coverage := make(map[models.TranslationEntity]*models.TranslationCoverage)
+	report := &models.MissingTranslationsReport{Locale: locale, Missing: []models.MissingTranslation{}}
+	for _, source := range sources {
+		if entityType != "" && source.EntityType != entityType {
+			continue
+		}
+
+		entry, ok := coverage[source.EntityType]
+		if !ok {
+			entry = &models.TranslationCoverage{EntityType: source.EntityType}
+			coverage[source.EntityType] = entry
+		}
+		entry.Total++
+
+		if _, translated := translations[translationKey(source.EntityType, source.EntityKey, source.Field)]; translated {
+			entry.Translated++
+			continue
+		}
+		entry.Missing++
+		report.Missing = append(report.Missing, source)
+	}
+
+	for _, entry := range coverage {
+		report.Coverage = append(report.Coverage, *entry)
+	}
+	sort.Slice(report.Coverage, func(i, j int) bool {
+		return report.Coverage[i].EntityType < report.Coverage[j].EntityType
+	})
+
+	return report, nil
+}
+
+// lookup returns the first translation found along the locale chain. The
+// default locale is the language of the source data, so reaching it ends the search.
+func (s *translationService) lookup(locales []string, entityType models.TranslationEntity, entityKey, field, source string) string {
+	key := translationKey(entityType, entityKey, field)
+	for _, locale := range locales {
+		if locale == s.defaultLocale {
+			break
+		}
+		translations, err := s.loadLocale(locale)
+		if err != nil {
+			log.Printf("Failed to load %s translations: %v", locale, err)
+			break
+		}
+		if value, ok := translations[key]; ok {
+			return value
+		}
+	}
+	return source
+}
+
+func (s *translationService) loadLocale(locale string) (map[string]string, error) {
+	s.mu.RLock()
+	translations, ok := s.cache[locale]
+	s.mu.RUnlock()
+	if ok {
+		return translations, nil
+	}
+
+	rows, err := s.translationRepo.GetByLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	translations = make(map[string]string, len(rows))
+	for _, row := range rows {
+		translations[translationKey(row.EntityType, row.EntityKey, row.Field)] = row.Value
+	}
+
+	s.mu.Lock()
+	s.cache[locale] = translations
+	s.mu.Unlock()
+
+	return translations, nil
+}
+
+func (s *translationService) invalidate(locale string) {
+	s.mu.Lock()
+	delete(s.cache, locale)
+	s.mu.Unlock()
+}
+
+func (s *translationService) isDefault(locales []string) bool {
+	return len(locales) == 0 || locales[0] == s.defaultLocale
+}
+
+func translationKey(entityType models.TranslationEntity, entityKey, field string) string {
+	return string(entityType) + "|" + entityKey + "|" + field
+}
+
+func perfumeKey(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func noteKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func personalityKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// personalitySourceTexts lists the quiz personality types, which live in code
+// rather than in the catalog tables ListSourceTexts reads
+func personalitySourceTexts() []models.MissingTranslation {
+	sources := make([]models.MissingTranslation, 0, 2*len(personalityTypes))
+	for _, personality := range personalityTypes {
+		key := personalityKey(personality.name)
+		sources = append(sources,
+			models.MissingTranslation{EntityType: models.TranslationEntityPersonality, EntityKey: key, Field: models.TranslationFieldName, SourceText: personality.name},
+			models.MissingTranslation{EntityType: models.TranslationEntityPersonality, EntityKey: key, Field: models.TranslationFieldDescription, SourceText: personality.description},
+		)
+	}
+	return sources
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// parseAcceptLanguage returns language tags ordered by their quality value
+func parseAcceptLanguage(header string) []string {
+	type weightedTag struct {
+		tag     string
+		quality float64
+	}
+
+	var tags []weightedTag
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		tags = append(tags, weightedTag{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].quality > tags[j].quality
+	})
+
+	result := make([]string, len(tags))
+	for i, tag := range tags {
+		result[i] = tag.tag
+	}
+	return result
+}
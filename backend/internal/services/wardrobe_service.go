@@ -0,0 +1,309 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"perfume-website/internal/models"
+)
+
+// ErrInvalidWardrobeRequest is returned when a wardrobe gap request lists no owned perfumes
+var ErrInvalidWardrobeRequest = errors.New("invalid wardrobe request")
+
+const (
+	DefaultWardrobeGaps  = 3
+	MaxWardrobeGaps      = 10
+	DefaultWardrobeLimit = 3
+	MaxWardrobeLimit     = 12
+
+	// wardrobeCoveredFit is the fit from which a perfume counts as covering a
+	// season, occasion, family or sillage level. Anything the collection covers
+	// less well is a gap, and a pick must reach it to fill one.
+	wardrobeCoveredFit = 0.5
+	// wardrobeDuplicateSimilarity is the content similarity to an owned
+	// perfume above which a pick would only duplicate it
+	wardrobeDuplicateSimilarity = 0.6
+	// wardrobeOverlapSimilarity is where a pick starts to be explained as overlapping
+	wardrobeOverlapSimilarity = 0.35
+	// wardrobeSimilarityPenalty is the share of its fit a pick loses as it
+	// approaches the closest owned perfume
+	wardrobeSimilarityPenalty = 0.5
+	// wardrobeDescriptionCueFit is the season fit of a perfume whose
+	// description names one of the season's cues, as the quiz scores it
+	wardrobeDescriptionCueFit = 0.6
+)
+
+// wardrobeSituationImpressions picks the impression profile that describes a
+// perfume for each current_situation of the quiz
+var wardrobeSituationImpressions = map[string]string{
+	"work":    "professional",
+	"date":    "romantic",
+	"casual":  "playful",
+	"special": "elegant",
+}
+
+// wardrobeSpot is one season, occasion, family or sillage level
+type wardrobeSpot struct {
+	dimension string
+	value     string
+}
+
+// wardrobeSpots lists every spot a collection is profiled on, in report order,
+// with the seasons of the climate
+func wardrobeSpots(climate string) []wardrobeSpot {
+	var spots []wardrobeSpot
+	for _, season := range climateSeasons(climate) {
+		spots = append(spots, wardrobeSpot{models.WardrobeDimensionSeason, season})
+	}
+	for _, occasion := range []string{"work", "date", "casual", "special"} {
+		spots = append(spots, wardrobeSpot{models.WardrobeDimensionOccasion, occasion})
+	}
+	families := make([]string, 0, len(noteFamilies))
+	for family := range noteFamilies {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+	for _, family := range families {
+		spots = append(spots, wardrobeSpot{models.WardrobeDimensionFamily, family})
+	}
+	for _, sillage := range []string{"light", "medium", "heavy", "very heavy"} {
+		spots = append(spots, wardrobeSpot{models.WardrobeDimensionSillage, sillage})
+	}
+	return spots
+}
+
+// wardrobeFit is how well a perfume covers a spot (0-1)
+func wardrobeFit(profile *layeringProfile, spot wardrobeSpot) float64 {
+	switch spot.dimension {
+	case models.WardrobeDimensionSeason:
+		return seasonFit(profile, spot.value)
+	case models.WardrobeDimensionOccasion:
+		return occasionFit(profile, spot.value)
+	case models.WardrobeDimensionFamily:
+		return profile.families[spot.value]
+	case models.WardrobeDimensionSillage:
+		if profile.sillage == 0 {
+			return 0
+		}
+		switch distance := abs(profile.sillage - sillageLevel(spot.value)); distance {
+		case 0:
+			return 1
+		case 1:
+			return 0.4
+		}
+	}
+	return 0
+}
+
+// seasonFit uses the season cues of the quiz: the strongest accord or note
+// family among them, or a cue in the description. A category the scent profile
+// algorithm rates for the season counts as well.
+func seasonFit(profile *layeringProfile, season string) float64 {
+	fit := 0.0
+	for _, cue := range seasonCues[season] {
+		fit = max(fit, profile.families[noteKey(cue)])
+	}
+	if len(matchedCues(profile.perfume.Description, seasonCues[season])) > 0 {
+		fit = max(fit, wardrobeDescriptionCueFit)
+	}
+	if suitability, ok := scentProfileSeasons[profile.perfume.Category][season]; ok {
+		fit = max(fit, suitability)
+	}
+	return fit
+}
+
+// occasionFit matches the impression profile of the situation, or the
+// category's rating for it by the scent profile algorithm
+func occasionFit(profile *layeringProfile, situation string) float64 {
+	fit := wearProfileFit(profile, impressionProfiles[wardrobeSituationImpressions[situation]])
+	if suitability, ok := scentProfileOccasions[profile.perfume.Category][scentProfileSituations[situation]]; ok {
+		fit = max(fit, suitability)
+	}
+	return fit
+}
+
+// wearProfileFit weighs the strongest matching accord or note family at 70%
+// and a matching sillage at 30%
+func wearProfileFit(profile *layeringProfile, wear wearProfile) float64 {
+	aroma := 0.0
+	for _, name := range wear.aromas {
+		aroma = max(aroma, profile.families[noteKey(name)])
+	}
+	fit := 0.7 * aroma
+	if matchesPerformance(wear.sillage, profile.perfume.Sillage) {
+		fit += 0.3
+	}
+	return fit
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// WardrobeService finds what a perfume collection is missing
+type WardrobeService struct {
+	catalog  *CatalogService
+	settings *RecommendationSettingsService
+}
+
+func NewWardrobeService(catalog *CatalogService, settings *RecommendationSettingsService) *WardrobeService {
+	return &WardrobeService{catalog: catalog, settings: settings}
+}
+
+// Gaps profiles the owned perfumes across seasons, occasions, aroma families
+// and sillage levels, and suggests perfumes for the weakest spots that are not
+// close copies of anything already owned
+func (s *WardrobeService) Gaps(req models.WardrobeGapsRequest) (*models.WardrobeGapsResponse, error) {
+	if len(req.OwnedPerfumeIDs) == 0 {
+		return nil, fmt.Errorf("%w: give at least one owned perfume", ErrInvalidWardrobeRequest)
+	}
+	maxGaps := req.MaxGaps
+	if maxGaps <= 0 {
+		maxGaps = DefaultWardrobeGaps
+	}
+	maxGaps = min(maxGaps, MaxWardrobeGaps)
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultWardrobeLimit
+	}
+	limit = min(limit, MaxWardrobeLimit)
+	_, climate, err := resolveLocation(req.Latitude, "", req.Climate, s.settings.Current().Season)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWardrobeRequest, err)
+	}
+
+	catalog, err := s.catalog.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	owned := make(map[uint]bool, len(req.OwnedPerfumeIDs))
+	var ownedIDs []uint
+	var ownedProfiles []*layeringProfile
+	for _, id := range req.OwnedPerfumeIDs {
+		if owned[id] {
+			continue
+		}
+		perfume, ok := catalog.Get(id)
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrPerfumeNotFound, id)
+		}
+		owned[id] = true
+		ownedIDs = append(ownedIDs, id)
+		ownedProfiles = append(ownedProfiles, newLayeringProfile(perfume))
+	}
+
+	response := &models.WardrobeGapsResponse{OwnedPerfumeIDs: ownedIDs, Climate: climate, Gaps: []models.WardrobeGap{}}
+	var gaps []models.WardrobeCoverage
+	for _, spot := range wardrobeSpots(climate) {
+		coverage := models.WardrobeCoverage{Dimension: spot.dimension, Value: spot.value, CoveredBy: []uint{}}
+		for _, profile := range ownedProfiles {
+			fit := wardrobeFit(profile, spot)
+			coverage.Coverage = max(coverage.Coverage, fit)
+			if fit >= wardrobeCoveredFit {
+				coverage.CoveredBy = append(coverage.CoveredBy, profile.perfume.ID)
+			}
+		}
+		coverage.Coverage = roundTo2(coverage.Coverage)
+		response.Coverage = append(response.Coverage, coverage)
+		if coverage.Coverage < wardrobeCoveredFit {
+			gaps = append(gaps, coverage)
+		}
+	}
+
+	var candidates []*layeringProfile
+	for i := range catalog.Perfumes {
+		if !owned[catalog.Perfumes[i].ID] {
+			candidates = append(candidates, newLayeringProfile(&catalog.Perfumes[i]))
+		}
+	}
+	picked := make(map[uint]bool)
+	for _, gap := range weakestGaps(gaps, maxGaps) {
+		picks := s.fillGap(catalog, gap, candidates, ownedProfiles, picked, limit)
+		for _, pick := range picks {
+			picked[pick.Perfume.ID] = true
+		}
+		response.Gaps = append(response.Gaps, models.WardrobeGap{WardrobeCoverage: gap, Picks: picks})
+	}
+	return response, nil
+}
+
+// weakestGaps returns up to n gaps, least covered first. The weakest gap of
+// each dimension comes before any second gap of the same dimension, so a
+// collection missing many aroma families still hears about its seasons.
+func weakestGaps(gaps []models.WardrobeCoverage, n int) []models.WardrobeCoverage {
+	sort.SliceStable(gaps, func(i, j int) bool { return gaps[i].Coverage < gaps[j].Coverage })
+
+	var first, rest []models.WardrobeCoverage
+	seen := make(map[string]bool)
+	for _, gap := range gaps {
+		if seen[gap.Dimension] {
+			rest = append(rest, gap)
+			continue
+		}
+		seen[gap.Dimension] = true
+		first = append(first, gap)
+	}
+
+	weakest := append(first, rest...)
+	return weakest[:min(n, len(weakest))]
+}
+
+// fillGap ranks the perfumes that cover the gap, discounted by how close each
+// one is to the collection. Near copies of an owned perfume and perfumes
+// already picked for a weaker gap are skipped.
+func (s *WardrobeService) fillGap(catalog *CatalogSnapshot, gap models.WardrobeCoverage, candidates, owned []*layeringProfile, picked map[uint]bool, limit int) []models.WardrobeGapPick {
+	spot := wardrobeSpot{gap.Dimension, gap.Value}
+	picks := []models.WardrobeGapPick{}
+	for _, candidate := range candidates {
+		if picked[candidate.perfume.ID] {
+			continue
+		}
+		fit := wardrobeFit(candidate, spot)
+		if fit < wardrobeCoveredFit {
+			continue
+		}
+
+		var closest *models.Perfume
+		similarity := 0.0
+		features := catalog.Features(candidate.perfume)
+		for _, profile := range owned {
+			if value := contentSimilarity(features, catalog.Features(profile.perfume)); closest == nil || value > similarity {
+				closest, similarity = profile.perfume, value
+			}
+		}
+		if similarity >= wardrobeDuplicateSimilarity {
+			continue
+		}
+
+		params := map[string]any{"similarity": roundTo2(similarity), "perfume": closest.Name}
+		explanations := []models.Explanation{
+			explain("wardrobe.fills", map[string]any{"value": strings.ToLower(gap.Value), "dimension": gap.Dimension, "fit": roundTo2(fit)}),
+		}
+		if similarity >= wardrobeOverlapSimilarity {
+			explanations = append(explanations, explain("wardrobe.overlap", params))
+		} else {
+			explanations = append(explanations, explain("wardrobe.distinct", params))
+		}
+		picks = append(picks, models.WardrobeGapPick{
+			Perfume:      *candidate.perfume,
+			Score:        roundTo2(fit * (1 - wardrobeSimilarityPenalty*similarity)),
+			Fit:          roundTo2(fit),
+			Similarity:   roundTo2(similarity),
+			ClosestOwned: closest.ID,
+			Explanations: explanations,
+		})
+	}
+
+	sort.SliceStable(picks, func(i, j int) bool {
+		if picks[i].Score != picks[j].Score {
+			return picks[i].Score > picks[j].Score
+		}
+		return picks[i].Perfume.ID < picks[j].Perfume.ID
+	})
+	return picks[:min(limit, len(picks))]
+}
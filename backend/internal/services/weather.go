@@ -0,0 +1,198 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"perfume-website/internal/models"
+)
+
+// Weather conditions a request can name, besides WeatherCurrent
+const (
+	WeatherHotHumid = "hot_humid"
+	WeatherHotDry   = "hot_dry"
+	WeatherWarm     = "warm"
+	WeatherMild     = "mild"
+	WeatherCold     = "cold"
+	WeatherRainy    = "rainy"
+
+	// WeatherCurrent asks the weather provider for the request's location and season
+	WeatherCurrent = "current"
+
+	// defaultHumidity is assumed when a request gives a temperature but no humidity
+	defaultHumidity = 50.0
+	// humidThreshold is the relative humidity from which hot weather counts as humid
+	humidThreshold = 60.0
+)
+
+// weatherProfiles describe the perfumes that suit each weather condition. Heat
+// amplifies projection and humidity makes sweet and heavy scents cloying, so
+// hot weather favours fresh and citrus accords with light sillage.
+var weatherProfiles = map[string]wearProfile{
+	WeatherHotHumid: {
+		aromas:    []string{"citrus", "fresh", "aquatic", "green"},
+		sillage:   []string{"light"},
+		longevity: []string{"light", "medium"},
+	},
+	WeatherHotDry: {
+		aromas:    []string{"citrus", "fresh", "aromatic", "green", "aquatic"},
+		sillage:   []string{"light", "medium"},
+		longevity: []string{"medium", "long"},
+	},
+	WeatherWarm: {
+		aromas:    []string{"citrus", "floral", "fruity", "fresh"},
+		sillage:   []string{"light", "medium"},
+		longevity: []string{"medium", "long"},
+	},
+	WeatherMild: {
+		aromas:    []string{"floral", "woody", "aromatic", "musk"},
+		sillage:   []string{"medium"},
+		longevity: []string{"medium", "long"},
+	},
+	WeatherCold: {
+		aromas:    []string{"amber", "oriental", "gourmand", "vanilla", "spicy", "oud"},
+		sillage:   []string{"medium", "heavy", "very heavy"},
+		longevity: []string{"long", "very long"},
+	},
+	WeatherRainy: {
+		aromas:    []string{"green", "woody", "earthy", "musk"},
+		sillage:   []string{"light", "medium"},
+		longevity: []string{"medium", "long"},
+	},
+}
+
+// weatherLabel is how a condition reads in explanations and match reasons
+func weatherLabel(condition string) string {
+	switch condition {
+	case WeatherHotHumid:
+		return "hot, humid"
+	case WeatherHotDry:
+		return "hot, dry"
+	default:
+		return condition
+	}
+}
+
+// isHotWeather reports whether heavy sillage turns cloying in the condition
+func isHotWeather(condition string) bool {
+	return condition == WeatherHotHumid || condition == WeatherHotDry
+}
+
+// WeatherReading is a temperature (°C) and relative humidity (0-100)
+type WeatherReading struct {
+	Temperature float64
+	Humidity    float64
+}
+
+// WeatherProvider reports the weather at the request's location. latitude is
+// nil when the request gave none; season is the one resolved for the request.
+type WeatherProvider interface {
+	Current(latitude *float64, season *models.SeasonInfo) (WeatherReading, error)
+}
+
+// StaticWeatherProvider returns a fixed reading per season, so weather-aware
+// recommendations work offline and give the same results in tests
+type StaticWeatherProvider struct {
+	Readings map[string]WeatherReading
+}
+
+// NewStaticWeatherProvider returns typical readings for each season: the
+// temperate seasons of a mid-latitude city and the monsoon seasons of Jakarta
+func NewStaticWeatherProvider() *StaticWeatherProvider {
+	return &StaticWeatherProvider{Readings: map[string]WeatherReading{
+		"spring": {Temperature: 15, Humidity: 65},
+		"summer": {Temperature: 26, Humidity: 60},
+		"fall":   {Temperature: 13, Humidity: 75},
+		"winter": {Temperature: 3, Humidity: 80},
+		"dry":    {Temperature: 32, Humidity: 70},
+		"wet":    {Temperature: 27, Humidity: 85},
+	}}
+}
+
+func (p *StaticWeatherProvider) Current(latitude *float64, season *models.SeasonInfo) (WeatherReading, error) {
+	reading, ok := p.Readings[season.Season]
+	if !ok {
+		return WeatherReading{}, fmt.Errorf("no weather reading for season '%s'", season.Season)
+	}
+	return reading, nil
+}
+
+// resolveWeather returns the weather to score against: the condition named in
+// the request, the one measured from its temperature and humidity, or the
+// provider's reading when the request asks for the current weather. It returns
+// nil when the request gives no weather at all.
+func resolveWeather(req models.AdvancedRecommendationRequest, season *models.SeasonInfo, provider WeatherProvider) (*models.WeatherInfo, error) {
+	condition := strings.ToLower(strings.TrimSpace(req.Weather))
+	if condition != "" && (req.Temperature != nil || req.Humidity != nil) {
+		return nil, fmt.Errorf("%w: give either weather or temperature and humidity", ErrInvalidQuizRequest)
+	}
+	if req.Humidity != nil && req.Temperature == nil {
+		return nil, fmt.Errorf("%w: humidity needs a temperature", ErrInvalidQuizRequest)
+	}
+
+	switch {
+	case req.Temperature != nil:
+		temperature, humidity := *req.Temperature, defaultHumidity
+		if math.IsNaN(temperature) || temperature < -50 || temperature > 60 {
+			return nil, fmt.Errorf("%w: temperature must be between -50 and 60 °C", ErrInvalidQuizRequest)
+		}
+		if req.Humidity != nil {
+			humidity = *req.Humidity
+			if math.IsNaN(humidity) || humidity < 0 || humidity > 100 {
+				return nil, fmt.Errorf("%w: humidity must be between 0 and 100", ErrInvalidQuizRequest)
+			}
+		}
+		return measuredWeather(WeatherReading{Temperature: temperature, Humidity: humidity}, models.WeatherSourceRequest), nil
+	case condition == WeatherCurrent:
+		reading, err := provider.Current(req.Latitude, season)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the current weather: %w", err)
+		}
+		return measuredWeather(reading, models.WeatherSourceProvider), nil
+	case condition != "":
+		if _, ok := weatherProfiles[condition]; !ok {
+			return nil, fmt.Errorf("%w: unknown weather '%s'", ErrInvalidQuizRequest, req.Weather)
+		}
+		return &models.WeatherInfo{Condition: condition, Source: models.WeatherSourceRequest}, nil
+	}
+	return nil, nil
+}
+
+// measuredWeather classifies a reading by how hot it feels
+func measuredWeather(reading WeatherReading, source string) *models.WeatherInfo {
+	feelsLike := math.Round(heatIndex(reading.Temperature, reading.Humidity)*10) / 10
+	condition := WeatherCold
+	switch {
+	case feelsLike >= 30 && reading.Humidity >= humidThreshold:
+		condition = WeatherHotHumid
+	case feelsLike >= 30:
+		condition = WeatherHotDry
+	case feelsLike >= 20:
+		condition = WeatherWarm
+	case feelsLike >= 10:
+		condition = WeatherMild
+	}
+	return &models.WeatherInfo{
+		Condition:   condition,
+		Source:      source,
+		Temperature: &reading.Temperature,
+		Humidity:    &reading.Humidity,
+		FeelsLike:   &feelsLike,
+	}
+}
+
+// heatIndex is the apparent temperature (°C) using the US National Weather
+// Service regression, which only applies from about 27 °C; below that the air
+// temperature is returned unchanged
+func heatIndex(celsius, humidity float64) float64 {
+	if celsius < 27 {
+		return celsius
+	}
+	t, rh := celsius*9/5+32, humidity
+	f := -42.379 + 2.04901523*t + 10.14333127*rh - 0.22475541*t*rh -
+		6.83783e-3*t*t - 5.481717e-2*rh*rh + 1.22874e-3*t*t*rh +
+		8.5282e-4*t*rh*rh - 1.99e-6*t*t*rh*rh
+	// The regression runs below the air temperature in dry heat
+	return math.Max((f-32)*5/9, celsius)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"perfume-website/internal/models"
+)
+
+func TestHeatIndex(t *testing.T) {
+	tests := []struct {
+		name        string
+		temperature float64
+		humidity    float64
+		feelsLike   float64
+	}{
+		{"below the regression", 26.9, 90, 26.9},
+		{"regression threshold, dry", 27, 40, 27},
+		{"regression threshold, humid", 27, 85, 30.2},
+		{"humid heat", 32, 70, 40.4},
+		{"dry heat keeps the air temperature", 32, 20, 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := heatIndex(tt.temperature, tt.humidity); math.Abs(got-tt.feelsLike) > 0.05 {
+				t.Errorf("heatIndex(%v, %v) = %.2f, want %.1f", tt.temperature, tt.humidity, got, tt.feelsLike)
+			}
+		})
+	}
+}
+
+func TestMeasuredWeather(t *testing.T) {
+	tests := []struct {
+		name        string
+		temperature float64
+		humidity    float64
+		condition   string
+		feelsLike   float64
+	}{
+		{"humidity lifts warm to hot", 27, 85, WeatherHotHumid, 30.2},
+		{"same heat, less humidity", 27, 60, WeatherWarm, 28.1},
+		{"humid at the threshold", 29, 60, WeatherHotHumid, 31},
+		{"hot below the humidity threshold", 35, 50, WeatherHotDry, 40.7},
+		{"dry heat", 32, 20, WeatherHotDry, 32},
+		{"warm from 20", 20, 50, WeatherWarm, 20},
+		{"mild below 20", 19.9, 50, WeatherMild, 19.9},
+		{"mild from 10", 10, 80, WeatherMild, 10},
+		{"cold below 10", 9.9, 80, WeatherCold, 9.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := measuredWeather(WeatherReading{Temperature: tt.temperature, Humidity: tt.humidity}, models.WeatherSourceRequest)
+			if info.Condition != tt.condition {
+				t.Errorf("condition = %q, want %q", info.Condition, tt.condition)
+			}
+			if *info.FeelsLike != tt.feelsLike {
+				t.Errorf("feels like = %v, want %v", *info.FeelsLike, tt.feelsLike)
+			}
+		})
+	}
+}
@@ -49,6 +49,7 @@ const QuizStep4: React.FC<QuizStep4Props> = ({ data, updateData, onSubmit, onPre
             value={data.season}
             onChange={(e) => updateData('season', e.target.value)}
           >
+            <option value="">Detect automatically</option>
             <option value="spring">Spring</option>
             <option value="summer">Summer</option>
             <option value="fall">Fall</option>
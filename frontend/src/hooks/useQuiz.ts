@@ -96,7 +96,7 @@ const initialQuizData: QuizData = {
   safeBet: false,
   priceRange: 'mid',
   currentSituation: 'casual',
-  season: 'spring',
+  season: '',
   timeOfDay: 'morning',
   desiredImpression: 'confident',
 };
@@ -33,7 +33,7 @@ export interface QuizPreferences {
 export interface AdvancedRecommendationRequest {
   quiz_preferences: QuizPreferences;
   current_situation: string;
-  season: string;
+  season?: string; // omitted: detected from the date and location
   time_of_day: string;
   desired_impression: string;
   max_results?: number;
@@ -239,7 +239,6 @@ export const convertSimpleQuizDataToAPI = (quizData: any): AdvancedRecommendatio
   return {
     quiz_preferences: preferences,
     current_situation: quizData.occasions?.[0] || 'casual', // Use first occasion
-    season: 'spring', // Default
     time_of_day: 'evening', // Default
     desired_impression: impressionMap[quizData.impression] || 'confident',
     max_results: 6,
@@ -287,7 +286,7 @@ export const convertQuizDataToAPI = (quizData: any): AdvancedRecommendationReque
       priceRange: quizData.priceRange || 'mid',
     },
     current_situation: quizData.currentSituation || 'casual',
-    season: quizData.season || 'spring',
+    season: quizData.season || undefined,
     time_of_day: quizData.timeOfDay || 'morning',
     desired_impression: quizData.desiredImpression || 'confident',
     max_results: 6,